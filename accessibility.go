@@ -0,0 +1,237 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// maxContrastChecks limits the number of text nodes checked for contrast problems.
+const maxContrastChecks = 500
+
+// AXNode represents a node of the accessibility tree. Ignored nodes are
+// omitted and their children attached to the nearest exposed ancestor.
+type AXNode struct {
+	Role        string                 `json:"role,omitempty"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Value       string                 `json:"value,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Children    []*AXNode              `json:"children,omitempty"`
+}
+
+// AXIssueKind is the kind of an accessibility issue.
+type AXIssueKind string
+
+const (
+	AXIssueImageAlt  AXIssueKind = "image-alt"  // Image without alternative text.
+	AXIssueFormLabel AXIssueKind = "form-label" // Form control without label.
+	AXIssueContrast  AXIssueKind = "contrast"   // Text with insufficient color contrast.
+)
+
+// AXIssue represents an obvious accessibility issue found in the accessibility tree.
+type AXIssue struct {
+	Kind   AXIssueKind `json:"kind"`
+	Role   string      `json:"role"`
+	Name   string      `json:"name,omitempty"`
+	Detail string      `json:"detail,omitempty"`
+
+	// BackendNodeID is the backend id of the associated DOM node.
+	BackendNodeID int64 `json:"backendNodeId,omitempty"`
+}
+
+// Form control roles that require an accessible name.
+var formControlRoles = map[string]bool{
+	"textbox":    true,
+	"searchbox":  true,
+	"combobox":   true,
+	"listbox":    true,
+	"checkbox":   true,
+	"radio":      true,
+	"slider":     true,
+	"spinbutton": true,
+	"switch":     true,
+}
+
+// contrastFunction returns the contrast ratio between the text color of a text
+// node and the nearest opaque background, with the minimum ratio required by
+// WCAG AA. It returns null if the background can not be determined.
+const contrastFunction = `function() {
+  const el = this.nodeType === Node.TEXT_NODE ? this.parentElement : this;
+  if (!el) return null;
+  const parse = (c) => {
+    const m = /rgba?\(([^)]+)\)/.exec(c);
+    if (!m) return null;
+    const p = m[1].split(/[\s,\/]+/).filter(Boolean).map(parseFloat);
+    return [p[0], p[1], p[2], p.length > 3 ? p[3] : 1];
+  };
+  const luminance = (c) => {
+    const f = (v) => { v /= 255; return v <= 0.03928 ? v / 12.92 : Math.pow((v + 0.055) / 1.055, 2.4); };
+    return 0.2126 * f(c[0]) + 0.7152 * f(c[1]) + 0.0722 * f(c[2]);
+  };
+  const style = getComputedStyle(el);
+  const fg = parse(style.color);
+  if (!fg) return null;
+  let bg = [255, 255, 255, 1];
+  for (let n = el; n; n = n.parentElement) {
+    const s = getComputedStyle(n);
+    if (s.backgroundImage !== 'none') return null;
+    const c = parse(s.backgroundColor);
+    if (c && c[3] > 0) { bg = c; break; }
+  }
+  const l1 = luminance(fg), l2 = luminance(bg);
+  const size = parseFloat(style.fontSize), bold = parseInt(style.fontWeight, 10) >= 700;
+  const large = size >= 24 || (bold && size >= 18.66);
+  return { ratio: (Math.max(l1, l2) + 0.05) / (Math.min(l1, l2) + 0.05), min: large ? 3 : 4.5 };
+}`
+
+func accessibilityTree[T As](res *T, issues *[]AXIssue, options ScreenshotOptions) chromedp.Action {
+	if !options.AccessibilityTree {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			nodes, err := accessibility.GetFullAXTree().Do(ctx)
+			if err != nil {
+				return err
+			}
+			*issues = axIssues(ctx, nodes)
+
+			buf, err := json.Marshal(axTree(nodes))
+			if err != nil {
+				return err
+			}
+			return setResult(res, buf, options.Files.AXTree)
+		}),
+	}
+}
+
+// axTree converts the flat node list reported by Accessibility.getFullAXTree to a tree.
+func axTree(nodes []*accessibility.Node) []*AXNode {
+	index := make(map[accessibility.NodeID]*accessibility.Node, len(nodes))
+	for _, n := range nodes {
+		index[n.NodeID] = n
+	}
+
+	var build func(n *accessibility.Node) []*AXNode
+	build = func(n *accessibility.Node) []*AXNode {
+		var children []*AXNode
+		for _, id := range n.ChildIDs {
+			if child, ok := index[id]; ok {
+				children = append(children, build(child)...)
+			}
+		}
+		if n.Ignored {
+			return children
+		}
+		node := &AXNode{
+			Role:        axValue(n.Role),
+			Name:        axValue(n.Name),
+			Description: axValue(n.Description),
+			Value:       axValue(n.Value),
+			Children:    children,
+		}
+		for _, p := range n.Properties {
+			if p.Value == nil || len(p.Value.Value) == 0 {
+				continue
+			}
+			var v interface{}
+			if err := json.Unmarshal(p.Value.Value, &v); err != nil {
+				continue
+			}
+			if node.Properties == nil {
+				node.Properties = make(map[string]interface{})
+			}
+			node.Properties[string(p.Name)] = v
+		}
+		return []*AXNode{node}
+	}
+
+	var tree []*AXNode
+	for _, n := range nodes {
+		if _, ok := index[n.ParentID]; !ok {
+			tree = append(tree, build(n)...)
+		}
+	}
+	return tree
+}
+
+// axIssues reports images without alternative text, unlabeled form controls
+// and text nodes with insufficient contrast.
+func axIssues(ctx context.Context, nodes []*accessibility.Node) (issues []AXIssue) {
+	checked := 0
+	for _, n := range nodes {
+		if n.Ignored {
+			continue
+		}
+		role, name := axValue(n.Role), axValue(n.Name)
+		issue := AXIssue{Role: role, Name: name, BackendNodeID: int64(n.BackendDOMNodeID)}
+		switch {
+		case (role == "image" || role == "img") && name == "":
+			issue.Kind = AXIssueImageAlt
+		case formControlRoles[role] && name == "":
+			issue.Kind = AXIssueFormLabel
+		case role == "StaticText" && name != "" && n.BackendDOMNodeID > 0 && checked < maxContrastChecks:
+			checked++
+			ratio, min, ok := contrastRatio(ctx, n.BackendDOMNodeID)
+			if !ok || ratio >= min {
+				continue
+			}
+			issue.Kind = AXIssueContrast
+			issue.Detail = fmt.Sprintf("contrast ratio %.2f:1 is below %.1f:1", ratio, min)
+		default:
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func contrastRatio(ctx context.Context, id cdp.BackendNodeID) (ratio, min float64, ok bool) {
+	obj, err := dom.ResolveNode().WithBackendNodeID(id).Do(ctx)
+	if err != nil || obj.ObjectID == "" {
+		return 0, 0, false
+	}
+	defer runtime.ReleaseObject(obj.ObjectID).Do(ctx) // nolint:errcheck
+
+	res, exp, err := runtime.CallFunctionOn(contrastFunction).
+		WithObjectID(obj.ObjectID).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil || exp != nil || res == nil || len(res.Value) == 0 {
+		return 0, 0, false
+	}
+	var v *struct {
+		Ratio float64 `json:"ratio"`
+		Min   float64 `json:"min"`
+	}
+	if err := json.Unmarshal(res.Value, &v); err != nil || v == nil {
+		return 0, 0, false
+	}
+	return v.Ratio, v.Min, true
+}
+
+func axValue(v *accessibility.Value) string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var i interface{}
+	if err := json.Unmarshal(v.Value, &i); err != nil {
+		return ""
+	}
+	if s, ok := i.(string); ok {
+		return s
+	}
+	return fmt.Sprint(i)
+}
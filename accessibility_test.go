@@ -0,0 +1,66 @@
+package screenshot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chromedp/cdproto/accessibility"
+)
+
+func axNode(id, parent string, role, name string, ignored bool, children ...string) *accessibility.Node {
+	n := &accessibility.Node{
+		NodeID:   accessibility.NodeID(id),
+		ParentID: accessibility.NodeID(parent),
+		Ignored:  ignored,
+		Role:     &accessibility.Value{Value: []byte(`"` + role + `"`)},
+		Name:     &accessibility.Value{Value: []byte(`"` + name + `"`)},
+	}
+	for _, c := range children {
+		n.ChildIDs = append(n.ChildIDs, accessibility.NodeID(c))
+	}
+	return n
+}
+
+func TestAXTree(t *testing.T) {
+	nodes := []*accessibility.Node{
+		axNode("1", "", "RootWebArea", "Example Domain", false, "2"),
+		axNode("2", "1", "generic", "", true, "3", "4"),
+		axNode("3", "2", "heading", "Example Domain", false),
+		axNode("4", "2", "image", "", false),
+	}
+
+	tree := axTree(nodes)
+	if len(tree) != 1 {
+		t.Fatalf("unexpected number of root nodes got %d instead of %d", len(tree), 1)
+	}
+	if role := tree[0].Role; role != "RootWebArea" {
+		t.Errorf("unexpected role of root node got %s instead of %s", role, "RootWebArea")
+	}
+	if n := len(tree[0].Children); n != 2 {
+		t.Fatalf("unexpected number of children got %d instead of %d", n, 2)
+	}
+	if name := tree[0].Children[0].Name; name != "Example Domain" {
+		t.Errorf("unexpected name of heading got %s instead of %s", name, "Example Domain")
+	}
+}
+
+func TestAXIssues(t *testing.T) {
+	nodes := []*accessibility.Node{
+		axNode("1", "", "RootWebArea", "Example Domain", false, "2", "3", "4", "5"),
+		axNode("2", "1", "image", "", false),
+		axNode("3", "1", "image", "logo", false),
+		axNode("4", "1", "textbox", "", false),
+		axNode("5", "1", "image", "", true),
+	}
+
+	issues := axIssues(context.Background(), nodes)
+	if len(issues) != 2 {
+		t.Fatalf("unexpected number of issues got %d instead of %d", len(issues), 2)
+	}
+	if kind := issues[0].Kind; kind != AXIssueImageAlt {
+		t.Errorf("unexpected kind of issue got %s instead of %s", kind, AXIssueImageAlt)
+	}
+	if kind := issues[1].Kind; kind != AXIssueFormLabel {
+		t.Errorf("unexpected kind of issue got %s instead of %s", kind, AXIssueFormLabel)
+	}
+}
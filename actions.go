@@ -0,0 +1,248 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultActionTimeout = 10 * time.Second
+
+// Action is a step of interaction with the page, run in order between loading
+// the page and capturing it. Exactly one of Click, Type, Press, Select, WaitFor,
+// Navigate and Evaluate must be set.
+type Action struct {
+	Click    string `yaml:"click,omitempty"`    // Selector of the element to click.
+	Type     string `yaml:"type,omitempty"`     // Selector of the element to type Text into.
+	Text     string `yaml:"text,omitempty"`     // Text typed by Type.
+	Press    string `yaml:"press,omitempty"`    // Key pressed on the focused element, e.g. Enter, Tab or a.
+	Select   string `yaml:"select,omitempty"`   // Selector of the select element to set Value to.
+	Value    string `yaml:"value,omitempty"`    // Value of the option chosen by Select.
+	WaitFor  string `yaml:"wait-for,omitempty"` // Selector of the element to wait for to be visible.
+	Navigate string `yaml:"navigate,omitempty"` // URL to navigate to, relative to the current page.
+	Evaluate string `yaml:"evaluate,omitempty"` // Script to evaluate, waiting for the returned promise.
+
+	// Timeout of the action, defaults to 10 seconds.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Kind returns the name of the action, e.g. click, or empty if none or more than one is set.
+func (a Action) Kind() string {
+	var kind string
+	for _, k := range []struct {
+		name string
+		set  bool
+	}{
+		{"click", a.Click != ""},
+		{"type", a.Type != ""},
+		{"press", a.Press != ""},
+		{"select", a.Select != ""},
+		{"wait-for", a.WaitFor != ""},
+		{"navigate", a.Navigate != ""},
+		{"evaluate", a.Evaluate != ""},
+	} {
+		if !k.set {
+			continue
+		}
+		if kind != "" {
+			return ""
+		}
+		kind = k.name
+	}
+	return kind
+}
+
+// Validate reports whether the action is well-formed.
+func (a Action) Validate() error {
+	kind := a.Kind()
+	if kind == "" {
+		return fmt.Errorf("screenshot: action must set exactly one of click, type, press, select, wait-for, navigate and evaluate")
+	}
+	if kind == "press" {
+		if _, ok := keyOf(a.Press); !ok {
+			return fmt.Errorf("screenshot: unknown key %q", a.Press)
+		}
+	}
+	if a.Timeout < 0 {
+		return fmt.Errorf("screenshot: negative timeout of %s action", kind)
+	}
+	return nil
+}
+
+// ImportActions imports actions by given byte with yaml configuration.
+// Format:
+// actions:
+//   - click: '#login'
+//   - type: '#username'
+//     text: 'alice'
+//   - press: Enter
+//   - select: '#country'
+//     value: 'de'
+//   - wait-for: '.dashboard'
+//     timeout: 30s
+//   - navigate: '/settings'
+//   - evaluate: 'window.scrollTo(0, 0)'
+func ImportActions(r []byte) ([]Action, error) {
+	type configs struct {
+		Actions []Action `yaml:"actions"`
+	}
+	var cfg configs
+	if err := yaml.Unmarshal(r, &cfg); err != nil {
+		return nil, err
+	}
+	for i, action := range cfg.Actions {
+		if err := action.Validate(); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	return cfg.Actions, nil
+}
+
+// Actions runs the actions in order once the page is loaded, before scrolling
+// and capturing. A failed action skips the rest of them and is reported as a
+// failure of the actions stage.
+func Actions(actions ...Action) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Actions = append(opts.Actions, actions...)
+	}
+}
+
+func runActions(options ScreenshotOptions) chromedp.Action {
+	if len(options.Actions) == 0 {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for i, action := range options.Actions {
+			if err := action.Validate(); err != nil {
+				return fmt.Errorf("action %d: %w", i+1, err)
+			}
+			timeout := action.Timeout
+			if timeout == 0 {
+				timeout = defaultActionTimeout
+			}
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			err := action.do().Do(tctx)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("action %d (%s): %w", i+1, action.Kind(), err)
+			}
+		}
+		return nil
+	})
+}
+
+func (a Action) do() chromedp.Action {
+	switch a.Kind() {
+	case "click":
+		return chromedp.Click(a.Click, chromedp.ByQuery, chromedp.NodeVisible)
+	case "type":
+		return chromedp.SendKeys(a.Type, a.Text, chromedp.ByQuery, chromedp.NodeVisible)
+	case "press":
+		key, _ := keyOf(a.Press)
+		return chromedp.KeyEvent(key)
+	case "select":
+		return selectOption(a.Select, a.Value)
+	case "wait-for":
+		return chromedp.WaitVisible(a.WaitFor, chromedp.ByQuery)
+	case "navigate":
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			// Relative to the current page.
+			var location string
+			if err := chromedp.Location(&location).Do(ctx); err != nil {
+				return err
+			}
+			base, err := url.Parse(location)
+			if err != nil {
+				return err
+			}
+			u, err := base.Parse(a.Navigate)
+			if err != nil {
+				return err
+			}
+			return navigateAndWaitFor(u.String(), "networkAlmostIdle").Do(ctx)
+		})
+	case "evaluate":
+		return chromedp.Evaluate(a.Evaluate, nil, awaitPromise)
+	}
+	return chromedp.Tasks{}
+}
+
+// keyNames maps the key values and codes, such as Enter or Digit1, to the
+// characters of the keys. A code shared by several characters resolves to
+// the one typed without shift, e.g. Digit1 to 1 rather than !.
+var keyNames = func() map[string]rune {
+	type entry struct {
+		r    rune
+		rank int // 0 for key values, 1 for unshifted codes, 2 for shifted codes.
+	}
+	entries := make(map[string]entry)
+	add := func(name string, r rune, rank int) {
+		if e, ok := entries[name]; ok && (e.rank < rank || (e.rank == rank && e.r < r)) {
+			return
+		}
+		entries[name] = entry{r: r, rank: rank}
+	}
+	for r, key := range kb.Keys {
+		add(key.Key, r, 0)
+		if key.Shift {
+			add(key.Code, r, 2)
+		} else {
+			add(key.Code, r, 1)
+		}
+	}
+	names := make(map[string]rune, len(entries))
+	for name, e := range entries {
+		names[name] = e.r
+	}
+	return names
+}()
+
+// keyOf returns the keys to send for a key name such as Enter or ArrowDown,
+// or a single character.
+func keyOf(name string) (string, bool) {
+	if utf8.RuneCountInString(name) == 1 {
+		return name, true
+	}
+	if r, ok := keyNames[name]; ok {
+		return string(r), true
+	}
+	return "", false
+}
+
+// selectOption sets the value of the select element, and dispatches the input
+// and change events as if chosen by the user.
+func selectOption(sel, value string) chromedp.Action {
+	const script = `(selector, value) => {
+  const el = document.querySelector(selector);
+  if (!el) throw new Error('no element matches ' + selector);
+  const option = Array.from(el.options || []).find((o) => o.value === value || o.text.trim() === value);
+  if (!option) throw new Error('no option ' + value);
+  el.value = option.value;
+  el.dispatchEvent(new Event('input', { bubbles: true }));
+  el.dispatchEvent(new Event('change', { bubbles: true }));
+  return true;
+}`
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := chromedp.WaitReady(sel, chromedp.ByQuery).Do(ctx); err != nil {
+			return err
+		}
+		args, err := json.Marshal([]string{sel, value})
+		if err != nil {
+			return err
+		}
+		return chromedp.Evaluate(fmt.Sprintf("(%s)(...%s)", script, args), nil).Do(ctx)
+	})
+}
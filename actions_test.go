@@ -0,0 +1,105 @@
+package screenshot
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/wabarc/helper"
+)
+
+func TestImportActions(t *testing.T) {
+	buf := []byte(`
+actions:
+  - click: '#login'
+  - type: '#username'
+    text: 'alice'
+  - press: Enter
+  - select: '#country'
+    value: 'de'
+  - wait-for: '.dashboard'
+    timeout: 30s
+  - navigate: '/settings'
+  - evaluate: 'window.scrollTo(0, 0)'
+`)
+	actions, err := ImportActions(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{"click", "type", "press", "select", "wait-for", "navigate", "evaluate"}
+	if len(actions) != len(kinds) {
+		t.Fatalf("unexpected number of actions, got %d instead of %d", len(actions), len(kinds))
+	}
+	for i, kind := range kinds {
+		if got := actions[i].Kind(); got != kind {
+			t.Errorf("unexpected kind of action %d, got %q instead of %q", i+1, got, kind)
+		}
+	}
+	if actions[4].Timeout != 30*time.Second {
+		t.Errorf("unexpected timeout, got %s", actions[4].Timeout)
+	}
+}
+
+func TestImportActionsInvalid(t *testing.T) {
+	tests := []string{
+		"actions:\n  - click: '#a'\n    navigate: '/b'\n",
+		"actions:\n  - text: 'alice'\n",
+		"actions:\n  - press: NoSuchKey\n",
+		"actions:\n  - click: '#a'\n    timeout: -1s\n",
+	}
+	for _, test := range tests {
+		if _, err := ImportActions([]byte(test)); err == nil {
+			t.Errorf("unexpected import without error: %q", test)
+		}
+	}
+}
+
+func TestKeyOf(t *testing.T) {
+	for name, want := range map[string]string{"Enter": "\r", "Escape": "\u001b", "a": "a", "Tab": "\t", "Digit1": "1", "KeyA": "a", "Slash": "/"} {
+		key, ok := keyOf(name)
+		if !ok || key != want {
+			t.Errorf("unexpected key of %s, got %q", name, key)
+		}
+	}
+}
+
+func TestScreenshotWithActions(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body>
+<input id="name">
+<select id="color"><option value="red">Red</option><option value="blue">Blue</option></select>
+<button id="submit" onclick="document.title = document.getElementById('name').value + ':' + document.getElementById('color').value">Submit</button>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, Actions(
+		Action{Type: "#name", Text: "alice"},
+		Action{Select: "#color", Value: "Blue"},
+		Action{Click: "#submit"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shot.Title != "alice:blue" {
+		t.Errorf("unexpected title, got %s", shot.Title)
+	}
+}
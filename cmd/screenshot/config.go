@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wabarc/screenshot"
+)
+
+func configUsage() {
+	e := os.Args[0]
+	fmt.Printf("usage:\n")
+	fmt.Printf("  %s config validate [file]\n\n", e)
+	fmt.Printf("example:\n  %s config validate config.yaml\n", e)
+	fmt.Printf("  %s -config config.yaml config validate\n\n", e)
+}
+
+// configCommand runs the config subcommand, and returns the exit code.
+func configCommand(args []string) int {
+	if len(args) < 1 || args[0] != "validate" || len(args) > 2 {
+		configUsage()
+		return 1
+	}
+
+	name := config
+	if len(args) == 2 {
+		name = args[1]
+	}
+	if name == "" {
+		configUsage()
+		return 1
+	}
+	cfg, err := screenshot.ReadConfig(name)
+	if err != nil {
+		printConfigError(err)
+		return 1
+	}
+	if err := checkTemplate(cfg.Output.Filename); err != nil {
+		fmt.Println(name, "=>", "invalid configuration:")
+		fmt.Println("  output.filename:", err)
+		return 1
+	}
+	fmt.Println(name, "=>", "ok")
+	return 0
+}
+
+// printConfigError prints each problem of an invalid configuration on its own line.
+func printConfigError(err error) {
+	var cfgErr *screenshot.ConfigError
+	if !errors.As(err, &cfgErr) {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(strings.TrimSuffix(err.Error(), cfgErr.Error()) + "invalid configuration:")
+	for _, problem := range cfgErr.Problems {
+		fmt.Println("  " + problem)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wabarc/screenshot"
+)
+
+// job is a URL to capture, with the options of its row in the input.
+type job struct {
+	URL     string
+	Index   int           // Position in the arguments and input, from 1.
+	Timeout time.Duration // Zero uses the timeout of the command.
+	Options []screenshot.ScreenshotOption
+}
+
+// openInput opens the input file, or stdin for -.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// inputFormat returns the format of the input by its extension, or auto.
+func inputFormat(name, format string) string {
+	if format != "" && format != "auto" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return "csv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	}
+	return "auto"
+}
+
+// readJobs reads the URLs of the input in the format plain, csv or jsonl,
+// detected from the first line if auto. Plain input has a URL per line, CSV
+// has a header row with a url column and the options of each URL in the
+// other columns, and each line of JSONL is an object with a url field and the
+// options. Blank lines and lines starting with # are skipped.
+func readJobs(r io.Reader, format string, emit func(job)) error {
+	br := bufio.NewReader(r)
+	var first string
+	for {
+		line, err := br.ReadString('\n')
+		first += line
+		if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+			if format == "auto" {
+				format = detectFormat(t)
+			}
+			break
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r = io.MultiReader(strings.NewReader(first), br)
+
+	switch format {
+	case "plain":
+		return readPlain(r, emit)
+	case "csv":
+		return readCSV(r, emit)
+	case "jsonl":
+		return readJSONL(r, emit)
+	}
+	return fmt.Errorf("unknown input format %q, want plain, csv or jsonl", format)
+}
+
+// detectFormat detects the format by the first line, which is the header of
+// CSV having a url column. URLs may contain commas, so that other lines are
+// plain.
+func detectFormat(line string) string {
+	if strings.HasPrefix(line, "{") {
+		return "jsonl"
+	}
+	cr := csv.NewReader(strings.NewReader(line))
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return "plain"
+	}
+	for _, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), "url") {
+			return "csv"
+		}
+	}
+	return "plain"
+}
+
+func readPlain(r io.Reader, emit func(job)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		emit(job{URL: line})
+	}
+	return scanner.Err()
+}
+
+func readCSV(r io.Reader, emit func(job)) error {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	urlCol := -1
+	for i, name := range header {
+		if name == "url" {
+			urlCol = i
+		}
+	}
+	if urlCol < 0 {
+		return fmt.Errorf("csv: missing url column in header %q", strings.Join(header, ","))
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		fields := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) && strings.TrimSpace(value) != "" {
+				fields[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		j, err := rowJob(fields)
+		if err != nil {
+			fmt.Printf("input line %d => %v\n", line, err)
+			continue
+		}
+		emit(j)
+	}
+}
+
+func readJSONL(r io.Reader, emit func(job)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var row map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&row); err != nil {
+			fmt.Printf("input line %d => %v\n", n, err)
+			continue
+		}
+		fields := make(map[string]string, len(row))
+		for name, value := range row {
+			if value != nil {
+				fields[strings.ToLower(name)] = fmt.Sprint(value)
+			}
+		}
+		j, err := rowJob(fields)
+		if err != nil {
+			fmt.Printf("input line %d => %v\n", n, err)
+			continue
+		}
+		emit(j)
+	}
+	return scanner.Err()
+}
+
+// rowJob returns the job of a row of the input, the fields other than url are
+// options: timeout, width, height, mobile, format, quality, pdf, html, har,
+// consent, fail-on-status, max-page-height, max-scrolls and load-more.
+func rowJob(fields map[string]string) (j job, err error) {
+	j.URL = fields["url"]
+	if j.URL == "" {
+		return j, errors.New("missing url")
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		var opt screenshot.ScreenshotOption
+		switch name {
+		case "url":
+			continue
+		case "timeout":
+			j.Timeout, err = parseTimeout(value)
+		case "width", "height", "quality", "max-page-height":
+			var n int64
+			if n, err = strconv.ParseInt(value, 10, 64); err == nil {
+				opt = map[string]func(int64) screenshot.ScreenshotOption{
+					"width":           screenshot.Width,
+					"height":          screenshot.Height,
+					"quality":         screenshot.Quality,
+					"max-page-height": screenshot.MaxPageHeight,
+				}[name](n)
+			}
+		case "max-scrolls":
+			var n int
+			if n, err = strconv.Atoi(value); err == nil {
+				opt = screenshot.MaxScrolls(n)
+			}
+		case "mobile", "pdf", "html", "har":
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				opt = map[string]func(bool) screenshot.ScreenshotOption{
+					"mobile": screenshot.Mobile,
+					"pdf":    screenshot.PrintPDF,
+					"html":   screenshot.RawHTML,
+					"har":    screenshot.DumpHAR,
+				}[name](b)
+			}
+		case "format":
+			if value != "png" && value != "jpg" && value != "jpeg" {
+				err = fmt.Errorf("want png or jpeg")
+			}
+			opt = screenshot.Format(value)
+		case "consent":
+			var mode screenshot.ConsentMode
+			if mode, err = screenshot.ParseConsentMode(value); err == nil {
+				opt = screenshot.Consent(mode)
+			}
+		case "fail-on-status":
+			var ranges []screenshot.StatusRange
+			if ranges, err = screenshot.ParseStatusRanges(value); err == nil {
+				opt = screenshot.FailOnStatus(ranges...)
+			}
+		case "load-more":
+			opt = screenshot.LoadMore(value, loadMoreClicks)
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return j, fmt.Errorf("%s %q: %w", name, value, err)
+		}
+		if opt != nil {
+			j.Options = append(j.Options, opt)
+		}
+	}
+	return j, nil
+}
+
+// parseTimeout parses a duration such as 30s, or a number of seconds.
+func parseTimeout(s string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if n < 0 {
+			return 0, errors.New("negative timeout")
+		}
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		return 0, errors.New("negative timeout")
+	}
+	return d, err
+}
+
+// resumeFile records the URLs captured completely, one per line, so that a
+// later run with the same file skips them.
+type resumeFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]bool
+}
+
+func openResume(name string) (*resumeFile, error) {
+	done := make(map[string]bool)
+	buf, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			done[line] = true
+		}
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &resumeFile{f: f, done: done}, nil
+}
+
+// Done reports whether the URL has been captured.
+func (r *resumeFile) Done(link string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done[link]
+}
+
+// Mark records the URL as captured.
+func (r *resumeFile) Mark(link string) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done[link] {
+		return nil
+	}
+	r.done[link] = true
+	_, err := fmt.Fprintln(r.f, link)
+	return err
+}
+
+func (r *resumeFile) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	for _, tt := range []struct {
+		line, want string
+	}{
+		{`https://example.com/`, "plain"},
+		{`url`, "csv"},
+		{`URL,width,mobile`, "csv"},
+		{`width, "url"`, "csv"},
+		{`https://example.com/?a=1,2`, "plain"},
+		{`https://example.com/a,b,c`, "plain"},
+		{`{"url": "https://example.com/"}`, "jsonl"},
+	} {
+		if got := detectFormat(tt.line); got != tt.want {
+			t.Errorf("unexpected format of %q got %s instead of %s", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestInputFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name, format, want string
+	}{
+		{"urls.csv", "auto", "csv"},
+		{"urls.CSV", "", "csv"},
+		{"urls.ndjson", "auto", "jsonl"},
+		{"urls.txt", "auto", "auto"},
+		{"-", "auto", "auto"},
+		{"urls.csv", "plain", "plain"},
+	} {
+		if got := inputFormat(tt.name, tt.format); got != tt.want {
+			t.Errorf("unexpected format of %s with %s got %s instead of %s", tt.name, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestReadJobs(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		input  string
+		format string
+		urls   []string
+	}{
+		{"plain", "# urls\n\nhttps://example.com/\n  https://example.org/  \n", "auto", []string{"https://example.com/", "https://example.org/"}},
+		{"plain with comma", "https://example.com/?a=1,2\nhttps://example.org/\n", "auto", []string{"https://example.com/?a=1,2", "https://example.org/"}},
+		{"csv of a column", "url\nhttps://example.com/\nhttps://example.org/", "auto", []string{"https://example.com/", "https://example.org/"}},
+		{"csv", "width,url,mobile\n800,https://example.com/,true\n,https://example.org/,\n", "auto", []string{"https://example.com/", "https://example.org/"}},
+		{"csv invalid row", "url,width\nhttps://example.com/,wide\nhttps://example.org/,800\n", "csv", []string{"https://example.org/"}},
+		{"jsonl", "{\"url\": \"https://example.com/\", \"width\": 800, \"pdf\": true}\nnot json\n{\"width\": 1}\n{\"url\": \"https://example.org/\"}", "auto", []string{"https://example.com/", "https://example.org/"}},
+		{"empty", "\n# nothing\n", "auto", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var urls []string
+			err := readJobs(strings.NewReader(tt.input), tt.format, func(j job) {
+				urls = append(urls, j.URL)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(urls, " ") != strings.Join(tt.urls, " ") {
+				t.Errorf("unexpected urls got %q instead of %q", urls, tt.urls)
+			}
+		})
+	}
+
+	if err := readJobs(strings.NewReader("width\n800\n"), "csv", func(job) {}); err == nil {
+		t.Error("unexpected csv without url column read without error")
+	}
+	if err := readJobs(strings.NewReader("https://example.com/"), "xml", func(job) {}); err == nil {
+		t.Error("unexpected unknown format read without error")
+	}
+}
+
+func TestRowJob(t *testing.T) {
+	j, err := rowJob(map[string]string{
+		"url":     "https://example.com/",
+		"timeout": "30",
+		"width":   "800",
+		"mobile":  "true",
+		"format":  "jpeg",
+		"consent": "reject",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.URL != "https://example.com/" || j.Timeout != 30*time.Second || len(j.Options) != 4 {
+		t.Errorf("unexpected job got %+v", j)
+	}
+
+	for _, fields := range []map[string]string{
+		{"width": "800"},
+		{"url": "https://example.com/", "width": "wide"},
+		{"url": "https://example.com/", "mobile": "maybe"},
+		{"url": "https://example.com/", "format": "gif"},
+		{"url": "https://example.com/", "timeout": "-1"},
+		{"url": "https://example.com/", "fail-on-status": "9xx"},
+		{"url": "https://example.com/", "color": "red"},
+	} {
+		if _, err := rowJob(fields); err == nil {
+			t.Errorf("unexpected job of %v without error", fields)
+		}
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"1.5", 1500 * time.Millisecond, true},
+		{"2m", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"-1s", 0, false},
+		{"soon", 0, false},
+	} {
+		got, err := parseTimeout(tt.in)
+		if (err == nil) != tt.ok || (tt.ok && got != tt.want) {
+			t.Errorf("unexpected timeout of %q got %s, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestResumeFile(t *testing.T) {
+	var none *resumeFile
+	if none.Done("https://example.com/") || none.Mark("https://example.com/") != nil || none.Close() != nil {
+		t.Error("unexpected nil resume file not a no-op")
+	}
+
+	name := filepath.Join(t.TempDir(), "done.txt")
+	r, err := openResume(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Done("https://example.com/") {
+		t.Error("unexpected url done in a new resume file")
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.Mark("https://example.com/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err = openResume(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !r.Done("https://example.com/") || r.Done("https://example.org/") {
+		t.Error("unexpected urls done after reopened")
+	}
+	if n := len(r.done); n != 1 {
+		t.Errorf("unexpected number of urls done got %d instead of 1", n)
+	}
+}
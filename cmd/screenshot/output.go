@@ -0,0 +1,255 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const defaultFilename = "{timestamp}-{domain}-{slug}.{ext}"
+
+var (
+	placeholderRe = regexp.MustCompile(`\{([a-z]+)\}`)
+	slugRe        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Placeholders of the filename template.
+var placeholders = map[string]string{
+	"host":      "host name of the URL, e.g. example.com",
+	"domain":    "host name with dots replaced by dashes, e.g. example-com",
+	"slug":      "path and query of the URL as lowercase words joined by dashes; for the root, -{slug} is dropped and {slug} is index",
+	"hash":      "first 12 hex digits of the SHA-256 of the URL",
+	"index":     "position of the URL in the arguments and input, from 1",
+	"date":      "date of the capture, e.g. 2006-01-02",
+	"time":      "time of the capture, e.g. 150405",
+	"timestamp": "date and time of the capture, e.g. 2006-01-02-150405.000",
+	"unix":      "seconds since the UNIX epoch of the capture",
+	"kind":      "kind of the artifact: image, html, pdf or har",
+	"ext":       "file extension of the artifact: png, jpg, html, pdf or har",
+}
+
+func filenameUsage() {
+	fmt.Printf("The -filename template names the artifacts of each URL relative to -output-dir,\n")
+	fmt.Printf("directories of the template are created, e.g. {host}/{date}/{slug}.{ext}.\n\n")
+	fmt.Printf("placeholders:\n")
+	names := make([]string, 0, len(placeholders))
+	for name := range placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-12s %s\n", "{"+name+"}", placeholders[name])
+	}
+	fmt.Printf("\ndefault:\n  %s\n", defaultFilename)
+}
+
+// checkTemplate reports unknown placeholders of the filename template.
+func checkTemplate(tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := placeholders[m[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s} of filename template %q", m[1], tmpl)
+		}
+	}
+	if !strings.Contains(tmpl, "{ext}") && !strings.Contains(tmpl, "{kind}") {
+		return fmt.Errorf("filename template %q must contain {ext} or {kind} to tell the artifacts apart", tmpl)
+	}
+	return nil
+}
+
+// output writes the artifacts of the captures to the directory, named by
+// the filename template, and records them in the manifest.
+type output struct {
+	dir      string
+	template string
+	conflict string // suffix, overwrite or skip
+
+	mu       sync.Mutex
+	manifest *os.File
+}
+
+func newOutput(dir, template, conflict, manifest string) (*output, error) {
+	if template == "" {
+		template = defaultFilename
+	}
+	if err := checkTemplate(template); err != nil {
+		return nil, err
+	}
+	switch conflict {
+	case "":
+		conflict = "suffix"
+	case "suffix", "overwrite", "skip":
+	default:
+		return nil, fmt.Errorf("invalid on-conflict %q, want suffix, overwrite or skip", conflict)
+	}
+	out := &output{dir: dir, template: template, conflict: conflict}
+	if manifest != "" {
+		f, err := os.OpenFile(manifest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		out.manifest = f
+	}
+	return out, nil
+}
+
+func (o *output) Close() error {
+	if o.manifest == nil {
+		return nil
+	}
+	return o.manifest.Close()
+}
+
+// filename renders the template for the artifact of the link.
+func (o *output) filename(link string, index int, kind, ext string, now time.Time) string {
+	var host, slug string
+	if u, err := url.Parse(link); err == nil {
+		host = strings.ToLower(u.Hostname())
+		slug = slugRe.ReplaceAllString(strings.ToLower(u.Path+" "+u.RawQuery), "-")
+		slug = strings.Trim(slug, "-")
+	}
+	if host == "" {
+		host = "unknown"
+	}
+	if len(slug) > 64 {
+		slug = strings.TrimRight(slug[:64], "-")
+	}
+	tmpl := o.template
+	if slug == "" {
+		// The root is named {timestamp}-{domain}.{ext} by the default.
+		tmpl = strings.ReplaceAll(tmpl, "-{slug}", "")
+		slug = "index"
+	}
+	sum := sha256.Sum256([]byte(link))
+
+	values := map[string]string{
+		"host":      host,
+		"domain":    strings.ReplaceAll(host, ".", "-"),
+		"slug":      slug,
+		"hash":      hex.EncodeToString(sum[:])[:12],
+		"index":     strconv.Itoa(index),
+		"date":      now.Format("2006-01-02"),
+		"time":      now.Format("150405"),
+		"timestamp": now.Format("2006-01-02-150405.000"),
+		"unix":      strconv.FormatInt(now.Unix(), 10),
+		"kind":      kind,
+		"ext":       ext,
+	}
+	name := placeholderRe.ReplaceAllStringFunc(tmpl, func(p string) string {
+		// Placeholders never introduce path separators.
+		return strings.NewReplacer("/", "-", `\`, "-").Replace(values[p[1:len(p)-1]])
+	})
+	return filepath.Join(o.dir, filepath.FromSlash(name))
+}
+
+// create creates the file of the artifact according to the collision
+// handling, and returns its path, or empty if skipped.
+func (o *output) create(name string, data []byte) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+	if o.conflict == "overwrite" {
+		return name, os.WriteFile(name, data, 0o600)
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		path := name
+		if i > 0 {
+			path = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		// Exclusive creation, so that concurrent captures never share a file.
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			if o.conflict == "skip" {
+				return "", nil
+			}
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return path, err
+	}
+}
+
+// write writes the artifacts of the link, and returns their paths by kind.
+func (o *output) write(link string, index int, now time.Time, artifacts map[string][]byte) (map[string]string, error) {
+	paths := make(map[string]string)
+	var errs []string
+	for _, kind := range []string{"image", "html", "pdf", "har"} {
+		data := artifacts[kind]
+		if data == nil {
+			continue
+		}
+		ext := kind
+		if kind == "image" {
+			ext = "png"
+			if mimetype.Detect(data).Is("image/jpeg") {
+				ext = "jpg"
+			}
+		}
+		path, err := o.create(o.filename(link, index, kind, ext, now), data)
+		switch {
+		case err != nil:
+			fmt.Println(link, "=>", err)
+			errs = append(errs, err.Error())
+		case path == "":
+			fmt.Println(link, "=>", kind, "skipped, file exists")
+		default:
+			fmt.Println(link, "=>", path)
+			paths[kind] = path
+		}
+	}
+	if len(errs) > 0 {
+		return paths, errors.New(strings.Join(errs, "; "))
+	}
+	return paths, nil
+}
+
+// manifestEntry is a line of the manifest, in JSON.
+type manifestEntry struct {
+	URL       string            `json:"url"`
+	Index     int               `json:"index"`
+	FinalURL  string            `json:"final_url,omitempty"`
+	Status    string            `json:"status"` // ok, partial or failed
+	Error     string            `json:"error,omitempty"`
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// record appends the entry to the manifest, if any.
+func (o *output) record(entry manifestEntry) {
+	if o.manifest == nil {
+		return
+	}
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, err := o.manifest.Write(append(buf, '\n')); err != nil {
+		fmt.Println(o.manifest.Name(), "=>", err)
+	}
+}
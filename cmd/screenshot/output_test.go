@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		tmpl string
+		ok   bool
+	}{
+		{"", true},
+		{defaultFilename, true},
+		{"{host}/{date}/{slug}.{ext}", true},
+		{"{hash}-{kind}", true},
+		{"{timestamp}-{domain}.png", false},
+		{"{host}-{path}.{ext}", false},
+		{"{Host}.{ext}", true},
+	} {
+		if err := checkTemplate(tt.tmpl); (err == nil) != tt.ok {
+			t.Errorf("unexpected check of %q got %v", tt.tmpl, err)
+		}
+	}
+}
+
+func TestOutputFilename(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 6e6, time.UTC)
+	long := "https://example.com/" + strings.Repeat("abc/", 30)
+	for _, tt := range []struct {
+		tmpl, link, want string
+	}{
+		{"", "https://example.com/", "2026-01-02-150405.006-example-com.png"},
+		{"", "https://example.com", "2026-01-02-150405.006-example-com.png"},
+		{"", "https://Example.COM/Foo/Bar.html?q=1&b=Two", "2026-01-02-150405.006-example-com-foo-bar-html-q-1-b-two.png"},
+		{"{host}/{date}/{slug}.{ext}", "https://example.com/", "example.com/2026-01-02/index.png"},
+		{"{host}/{date}/{slug}.{ext}", "https://example.com/a/b", "example.com/2026-01-02/a-b.png"},
+		{"{slug}.{ext}", long, strings.TrimRight(strings.Repeat("abc-", 16), "-") + ".png"},
+		{"{index}-{kind}-{time}-{unix}.{ext}", "https://example.com/", "3-image-150405-1767366245.png"},
+		{"{hash}.{ext}", "https://example.com/", "0f115db062b7.png"},
+		{"{host}.{ext}", "not a url", "unknown.png"},
+		{"{host}/{slug}.{ext}", "https://example.com/%2F..%5C", "example.com/index.png"},
+	} {
+		o := &output{dir: "out", template: tt.tmpl}
+		if o.template == "" {
+			o.template = defaultFilename
+		}
+		got := o.filename(tt.link, 3, "image", "png", now)
+		if want := filepath.Join("out", filepath.FromSlash(tt.want)); got != want {
+			t.Errorf("unexpected filename of %s with %q got %s instead of %s", tt.link, tt.tmpl, got, want)
+		}
+	}
+}
+
+func TestOutputFilenameSeparator(t *testing.T) {
+	o := &output{dir: "out", template: "{kind}/{ext}"}
+	got := o.filename("https://example.com/", 1, `a/b`, `c\d`, time.Now())
+	if want := filepath.Join("out", "a-b", "c-d"); got != want {
+		t.Errorf("unexpected filename got %s instead of %s", got, want)
+	}
+}
+
+func TestOutputCreate(t *testing.T) {
+	for _, tt := range []struct {
+		conflict string
+		paths    []string
+		content  string
+	}{
+		{"suffix", []string{"a.png", "a-1.png", "a-2.png"}, "0"},
+		{"skip", []string{"a.png", "", ""}, "0"},
+		{"overwrite", []string{"a.png", "a.png", "a.png"}, "2"},
+	} {
+		t.Run(tt.conflict, func(t *testing.T) {
+			dir := t.TempDir()
+			o, err := newOutput(dir, "", tt.conflict, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Join(dir, "sub", "a.png")
+			for i, want := range tt.paths {
+				path, err := o.create(name, []byte{byte('0' + i)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want != "" {
+					want = filepath.Join(dir, "sub", want)
+				}
+				if path != want {
+					t.Errorf("unexpected path got %q instead of %q", path, want)
+				}
+			}
+			buf, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != tt.content {
+				t.Errorf("unexpected content got %q instead of %q", buf, tt.content)
+			}
+		})
+	}
+
+	if _, err := newOutput(t.TempDir(), "", "rename", ""); err == nil {
+		t.Error("unexpected invalid on-conflict without error")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/wabarc/screenshot"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// profilesDir returns the directory of the named profiles, which is
+// $SCREENSHOT_PROFILES or screenshot/profiles in the user config directory.
+func profilesDir() (string, error) {
+	if dir := os.Getenv("SCREENSHOT_PROFILES"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screenshot", "profiles"), nil
+}
+
+func profileDir(name string) (string, error) {
+	if !profileName.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func profileUsage() {
+	e := os.Args[0]
+	fmt.Printf("usage:\n")
+	fmt.Printf("  %s profile create name\n", e)
+	fmt.Printf("  %s profile login name url\n", e)
+	fmt.Printf("  %s profile list\n", e)
+	fmt.Printf("  %s profile delete name\n\n", e)
+	fmt.Printf("example:\n  %s profile login example https://example.com/login\n", e)
+	fmt.Printf("  %s -profile example https://example.com/\n\n", e)
+}
+
+// profile runs the profile subcommand, and returns the exit code.
+func profile(args []string) int {
+	if len(args) < 1 {
+		profileUsage()
+		return 1
+	}
+
+	var err error
+	switch cmd := args[0]; {
+	case cmd == "list" && len(args) == 1:
+		err = listProfiles()
+	case cmd == "create" && len(args) == 2:
+		var dir string
+		if dir, err = profileDir(args[1]); err == nil {
+			if _, e := os.Stat(dir); e == nil {
+				err = fmt.Errorf("profile %s exists", args[1])
+			} else if err = os.MkdirAll(dir, 0o700); err == nil {
+				fmt.Println(args[1], "=>", dir)
+			}
+		}
+	case cmd == "login" && len(args) == 3:
+		err = loginProfile(args[1], args[2])
+	case cmd == "delete" && len(args) == 2:
+		var dir string
+		if dir, err = profileDir(args[1]); err == nil {
+			if screenshot.ProfileLocked(dir) {
+				err = fmt.Errorf("profile %s is in use", args[1])
+			} else {
+				err = os.RemoveAll(dir)
+			}
+		}
+	default:
+		profileUsage()
+		return 1
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
+
+func listProfiles() error {
+	dir, err := profilesDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if screenshot.ProfileLocked(path) {
+			fmt.Println(entry.Name(), "=>", path, "(in use)")
+			continue
+		}
+		fmt.Println(entry.Name(), "=>", path)
+	}
+	return nil
+}
+
+func loginProfile(name, link string) error {
+	dir, err := profileDir(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("profile %s not found, create it first", name)
+	}
+	input, err := url.Parse(link)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Log in and close the browser window to save the session of profile", name)
+	return screenshot.Login(context.Background(), input, screenshot.Profile(dir))
+}
@@ -0,0 +1,746 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the configuration file of the command, in YAML or JSON. The
+// cookies, storage and rules are keyed by domain, and are the per-domain
+// overrides of the capture settings, see ImportCookies, ImportStorage,
+// ImportSessionStorage, ImportIndexedDB and ImportRules for their format.
+//
+// String values may refer to environment variables as ${NAME}, or as
+// ${NAME:-default} with a default used if the variable is unset or empty.
+// A reference to an unset variable without default is an error, $${ is
+// kept as ${ for the template literals of scripts. Durations are such as 30s
+// or 1m30s, or a number of seconds.
+type Config struct {
+	Browser BrowserConfig `yaml:"browser,omitempty"`
+	Remote  RemoteConfig  `yaml:"remote,omitempty"`
+	Capture CaptureConfig `yaml:"capture,omitempty"`
+	Output  OutputConfig  `yaml:"output,omitempty"`
+
+	Cookies        map[string][]Cookie        `yaml:"cookies,omitempty"`
+	LocalStorage   map[string][]LocalStorage  `yaml:"local-storage,omitempty"`
+	SessionStorage map[string][]LocalStorage  `yaml:"session-storage,omitempty"`
+	IndexedDB      map[string][]IndexedDBItem `yaml:"indexed-db,omitempty"`
+	Rules          Rules                      `yaml:"rules,omitempty"`
+	Actions        []Action                   `yaml:"actions,omitempty"`
+}
+
+// BrowserConfig is the settings of the browser launched for each capture,
+// see LaunchOptions. Unset settings keep the ones of DefaultLaunchOptions.
+type BrowserConfig struct {
+	ExecPath    string   `yaml:"exec-path,omitempty"`
+	Headless    *bool    `yaml:"headless,omitempty"`
+	NoSandbox   *bool    `yaml:"no-sandbox,omitempty"`
+	DisableGPU  *bool    `yaml:"disable-gpu,omitempty"`
+	UserAgent   string   `yaml:"user-agent,omitempty"`
+	ProxyServer string   `yaml:"proxy-server,omitempty"`
+	Env         []string `yaml:"env,omitempty"`
+	UserDataDir string   `yaml:"user-data-dir,omitempty"`
+	Profile     string   `yaml:"profile,omitempty"`
+	Extensions  []string `yaml:"extensions,omitempty"`
+
+	Flags       map[string]interface{} `yaml:"flags,omitempty"`
+	RemoveFlags []string               `yaml:"remove-flags,omitempty"`
+
+	WindowWidth  int `yaml:"window-width,omitempty"`
+	WindowHeight int `yaml:"window-height,omitempty"`
+
+	WSURLReadTimeout time.Duration `yaml:"ws-url-read-timeout,omitempty"`
+}
+
+// RemoteConfig is the settings of the remote browsers, see NewChromeRemotePool.
+type RemoteConfig struct {
+	Addrs               []string      `yaml:"addrs,omitempty"`
+	HealthCheckInterval time.Duration `yaml:"health-check-interval,omitempty"`
+	HealthCheckTimeout  time.Duration `yaml:"health-check-timeout,omitempty"`
+	DisableHealthChecks bool          `yaml:"disable-health-checks,omitempty"`
+}
+
+// CaptureConfig is the settings of each capture, see ScreenshotOptions.
+type CaptureConfig struct {
+	Timeout time.Duration `yaml:"timeout,omitempty"` // Timeout of the captures.
+	Retries int           `yaml:"retries,omitempty"` // Maximum number of attempts, see Retry.
+
+	// Viewport emulated for the captures if both width and height are set,
+	// overridden by the viewports of the domains.
+	Width       int64   `yaml:"width,omitempty"`
+	Height      int64   `yaml:"height,omitempty"`
+	Mobile      bool    `yaml:"mobile,omitempty"`
+	ScaleFactor float64 `yaml:"scale-factor,omitempty"`
+	Format      string  `yaml:"format,omitempty"`
+	Quality     int64   `yaml:"quality,omitempty"`
+	MaxWidth    int64   `yaml:"max-width,omitempty"`
+	MaxHeight   int64   `yaml:"max-height,omitempty"`
+
+	PDF               bool     `yaml:"pdf,omitempty"`
+	HTML              bool     `yaml:"html,omitempty"`
+	HAR               bool     `yaml:"har,omitempty"`
+	Performance       bool     `yaml:"performance,omitempty"`
+	AccessibilityTree bool     `yaml:"accessibility-tree,omitempty"`
+	DOMSnapshot       bool     `yaml:"dom-snapshot,omitempty"`
+	SnapshotStyles    []string `yaml:"snapshot-styles,omitempty"`
+	Screencast        bool     `yaml:"screencast,omitempty"`
+	ScreencastFrames  bool     `yaml:"screencast-frames,omitempty"`
+
+	// Files the artifacts are appended to, see AppendToFile.
+	Files FilesConfig `yaml:"files,omitempty"`
+
+	FailOnStatus string `yaml:"fail-on-status,omitempty"` // e.g. 404,410 or 4xx,5xx
+	Consent      string `yaml:"consent,omitempty"`        // reject, accept or hide
+
+	BeforeNavigate []string          `yaml:"before-navigate,omitempty"`
+	AfterLoad      []string          `yaml:"after-load,omitempty"`
+	BeforeCapture  []string          `yaml:"before-capture,omitempty"`
+	CSS            []string          `yaml:"css,omitempty"`
+	Hide           []string          `yaml:"hide,omitempty"`
+	Remove         []string          `yaml:"remove,omitempty"`
+	Headers        map[string]string `yaml:"headers,omitempty"`
+
+	ScrollStep     int64         `yaml:"scroll-step,omitempty"`
+	ScrollInterval time.Duration `yaml:"scroll-interval,omitempty"`
+	MaxScrolls     int           `yaml:"max-scrolls,omitempty"`
+	MaxPageHeight  int64         `yaml:"max-page-height,omitempty"`
+	LoadMore       string        `yaml:"load-more,omitempty"`
+	LoadMoreClicks int           `yaml:"load-more-clicks,omitempty"`
+
+	// Proxies used in turn by the captures, see RotateProxy.
+	Proxies     []string `yaml:"proxies,omitempty"`
+	ProxyBypass []string `yaml:"proxy-bypass,omitempty"`
+}
+
+// FilesConfig is the files the artifacts are appended to, see Files.
+type FilesConfig struct {
+	Image       string `yaml:"image,omitempty"`
+	HTML        string `yaml:"html,omitempty"`
+	PDF         string `yaml:"pdf,omitempty"`
+	HAR         string `yaml:"har,omitempty"`
+	Performance string `yaml:"performance,omitempty"`
+	AXTree      string `yaml:"ax-tree,omitempty"`
+	DOM         string `yaml:"dom,omitempty"`
+	Screencast  string `yaml:"screencast,omitempty"`
+}
+
+// OutputConfig is the settings of writing the artifacts by the command.
+type OutputConfig struct {
+	Dir      string `yaml:"dir,omitempty"`      // Directory of the artifacts, the working directory if empty.
+	Progress bool   `yaml:"progress,omitempty"` // Print progress of captures to stderr.
+
+	// File the cookies of the browser are saved to after the captures, in
+	// the Netscape cookies.txt format if it has a .txt extension, or JSON.
+	Cookies string `yaml:"cookies,omitempty"`
+
+	// Template of the file names of the artifacts relative to dir, such as
+	// {host}/{date}/{slug}.{ext}, see the -filename flag of the command.
+	Filename string `yaml:"filename,omitempty"`
+	// Handling of existing files: suffix the new ones with -1, -2, ... by
+	// default, overwrite, or skip.
+	OnConflict string `yaml:"on-conflict,omitempty"`
+	// File each captured URL and the paths of its artifacts are appended to,
+	// a JSON object per line.
+	Manifest string `yaml:"manifest,omitempty"`
+}
+
+// ConfigError reports the invalid settings of a configuration.
+type ConfigError struct {
+	Problems []string // Each problem is prefixed with the path of the setting, e.g. capture.quality.
+}
+
+func (e *ConfigError) Error() string {
+	return "config: " + strings.Join(e.Problems, "; ")
+}
+
+// LoadConfig parses the configuration in YAML or JSON, expanding environment
+// variables, and validates it. Unknown settings are errors.
+func LoadConfig(buf []byte) (*Config, error) {
+	buf, err := expandConfig(buf)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.UnmarshalStrict(buf, cfg); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ReadConfig reads the configuration file, see LoadConfig.
+func ReadConfig(name string) (*Config, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+var configVarRe = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:?-[^}]*)?\}`)
+
+// expandConfig expands the environment variables in the string values of the
+// configuration, and converts the values to the types of their settings. The
+// values expanded are kept as strings, such as tokens and passwords, unless
+// the setting is a number, a boolean or a duration. Durations given as bare
+// numbers are seconds, the same as the -timeout flag of the command.
+func expandConfig(buf []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	var problems []string
+	doc = expandValue(doc, reflect.TypeOf(Config{}), "", &problems)
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	return yaml.Marshal(doc)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// expandValue expands the value decoded from YAML, of the setting of type t,
+// which is nil if unknown.
+func expandValue(v interface{}, t reflect.Type, path string, problems *[]string) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		for key, val := range v {
+			v[key] = expandValue(val, fieldType(t, fmt.Sprint(key)), joinPath(path, fmt.Sprint(key)), problems)
+		}
+	case []interface{}:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i, val := range v {
+			v[i] = expandValue(val, elem, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case string:
+		s, expanded := expandString(v, path, problems)
+		if expanded || t == durationType {
+			return convertValue(s, t, path, problems)
+		}
+		return s
+	case int, int64, uint64, float64:
+		if t == durationType {
+			return convertValue(fmt.Sprint(v), t, path, problems)
+		}
+	}
+	return v
+}
+
+// expandString expands the environment variables of the string, and reports
+// whether it refers to any.
+func expandString(v, path string, problems *[]string) (s string, expanded bool) {
+	s = configVarRe.ReplaceAllStringFunc(v, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		expanded = true
+		m := configVarRe.FindStringSubmatch(ref)
+		name, def := m[1], m[2]
+		value, ok := os.LookupEnv(name)
+		switch {
+		case strings.HasPrefix(def, ":-") && value == "":
+			return def[2:]
+		case strings.HasPrefix(def, "-") && !ok:
+			return def[1:]
+		case !ok:
+			*problems = append(*problems, fmt.Sprintf("%s: environment variable %s is not set, escape $ as $$ if it is not a variable", path, name))
+		}
+		return value
+	})
+	return s, expanded
+}
+
+// convertValue converts the string to the type of the setting, a number of
+// seconds or a duration such as 1m30s for durations.
+func convertValue(s string, t reflect.Type, path string, problems *[]string) interface{} {
+	if t == nil {
+		return s
+	}
+	var v interface{}
+	var err error
+	switch {
+	case t == durationType:
+		if n, perr := strconv.ParseFloat(s, 64); perr == nil {
+			return time.Duration(n * float64(time.Second)).String()
+		}
+		if _, err = time.ParseDuration(s); err == nil {
+			return s
+		}
+		err = fmt.Errorf("want seconds or a duration such as 30s")
+	case t.Kind() == reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		v, err = strconv.ParseUint(s, 10, 64)
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		return s
+	}
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
+		}
+		*problems = append(*problems, fmt.Sprintf("%s: invalid %s %q, %v", path, t, s, err))
+		return s
+	}
+	return v
+}
+
+// fieldType returns the type of the setting named key of a setting of type t.
+func fieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if name == key && f.PkgPath == "" {
+				return f.Type
+			}
+		}
+	}
+	return nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Validate reports all the invalid settings of the configuration as a *ConfigError.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+	nonNegative := func(path string, n int64) {
+		if n < 0 {
+			add(path, "must not be negative, got %d", n)
+		}
+	}
+
+	b := c.Browser
+	nonNegative("browser.window-width", int64(b.WindowWidth))
+	nonNegative("browser.window-height", int64(b.WindowHeight))
+	nonNegative("browser.ws-url-read-timeout", int64(b.WSURLReadTimeout))
+	for i, env := range b.Env {
+		if !strings.Contains(env, "=") {
+			add(fmt.Sprintf("browser.env[%d]", i), "must be KEY=value, got %q", env)
+		}
+	}
+	for name := range b.Flags {
+		if strings.TrimLeft(name, "-") == "" {
+			add("browser.flags", "empty flag name")
+		}
+	}
+	if b.Profile != "" && b.UserDataDir != "" {
+		add("browser.profile", "must not be set with user-data-dir")
+	}
+	if _, _, err := parseProxy(b.ProxyServer); err != nil {
+		add("browser.proxy-server", "%v", err)
+	}
+
+	r := c.Remote
+	for i, addr := range r.Addrs {
+		if strings.TrimSpace(addr) == "" {
+			add(fmt.Sprintf("remote.addrs[%d]", i), "must not be empty")
+		}
+	}
+	nonNegative("remote.health-check-interval", int64(r.HealthCheckInterval))
+	nonNegative("remote.health-check-timeout", int64(r.HealthCheckTimeout))
+
+	cp := c.Capture
+	nonNegative("capture.timeout", int64(cp.Timeout))
+	nonNegative("capture.retries", int64(cp.Retries))
+	nonNegative("capture.width", cp.Width)
+	nonNegative("capture.height", cp.Height)
+	nonNegative("capture.max-width", cp.MaxWidth)
+	nonNegative("capture.max-height", cp.MaxHeight)
+	if cp.ScaleFactor < 0 {
+		add("capture.scale-factor", "must not be negative, got %g", cp.ScaleFactor)
+	}
+	switch cp.Format {
+	case "", "png", "jpg", "jpeg":
+	default:
+		add("capture.format", "must be png or jpeg, got %q", cp.Format)
+	}
+	if cp.Quality < 0 || cp.Quality > 100 {
+		add("capture.quality", "must be between 0 and 100, got %d", cp.Quality)
+	}
+	if _, err := ParseStatusRanges(cp.FailOnStatus); err != nil {
+		add("capture.fail-on-status", "%v", err)
+	}
+	if _, err := ParseConsentMode(cp.Consent); err != nil {
+		add("capture.consent", "%v", err)
+	}
+	nonNegative("capture.scroll-step", cp.ScrollStep)
+	nonNegative("capture.scroll-interval", int64(cp.ScrollInterval))
+	nonNegative("capture.max-scrolls", int64(cp.MaxScrolls))
+	nonNegative("capture.max-page-height", cp.MaxPageHeight)
+	nonNegative("capture.load-more-clicks", int64(cp.LoadMoreClicks))
+	if cp.LoadMoreClicks > 0 && cp.LoadMore == "" {
+		add("capture.load-more-clicks", "requires load-more")
+	}
+	validateHeaders("capture.headers", cp.Headers, add)
+	for i, proxy := range cp.Proxies {
+		if _, _, err := parseProxy(proxy); err != nil || proxy == "" {
+			add(fmt.Sprintf("capture.proxies[%d]", i), "invalid proxy %q", proxy)
+		}
+	}
+
+	switch c.Output.OnConflict {
+	case "", "suffix", "overwrite", "skip":
+	default:
+		add("output.on-conflict", "must be suffix, overwrite or skip, got %q", c.Output.OnConflict)
+	}
+
+	for _, domain := range sortedKeys(c.Cookies) {
+		if domain == "" {
+			add("cookies", "empty domain")
+		}
+		for i, cookie := range c.Cookies[domain] {
+			path := fmt.Sprintf("cookies.%s[%d]", domain, i)
+			if cookie.Name == "" {
+				add(path+".name", "must not be empty")
+			}
+			switch cookie.SameSite {
+			case "", "Strict", "Lax", "None":
+			default:
+				add(path+".sameSite", "must be Strict, Lax or None, got %q", cookie.SameSite)
+			}
+			switch cookie.Priority {
+			case "", "Low", "Medium", "High":
+			default:
+				add(path+".priority", "must be Low, Medium or High, got %q", cookie.Priority)
+			}
+		}
+	}
+	for _, s := range []struct {
+		name    string
+		storage map[string][]LocalStorage
+	}{
+		{"local-storage", c.LocalStorage},
+		{"session-storage", c.SessionStorage},
+	} {
+		name, storage := s.name, s.storage
+		for _, domain := range sortedKeys(storage) {
+			if domain == "" {
+				add(name, "empty domain")
+			}
+			for i, item := range storage[domain] {
+				if item.Key == "" {
+					add(fmt.Sprintf("%s.%s[%d].key", name, domain, i), "must not be empty")
+				}
+			}
+		}
+	}
+	for _, domain := range sortedKeys(c.IndexedDB) {
+		if domain == "" {
+			add("indexed-db", "empty domain")
+		}
+		for i, item := range c.IndexedDB[domain] {
+			path := fmt.Sprintf("indexed-db.%s[%d]", domain, i)
+			if item.Database == "" {
+				add(path+".database", "must not be empty")
+			}
+			if item.Store == "" {
+				add(path+".store", "must not be empty")
+			}
+			if item.Key == "" {
+				add(path+".key", "must not be empty")
+			}
+		}
+	}
+	for _, domain := range sortedKeys(c.Rules) {
+		rule := c.Rules[domain]
+		path := "rules." + domain
+		if domain == "" {
+			add("rules", "empty domain")
+		}
+		nonNegative(path+".wait-timeout", int64(rule.WaitTimeout))
+		validateHeaders(path+".headers", rule.Headers, add)
+		if vp := rule.Viewport; vp != nil {
+			if vp.Width <= 0 || vp.Height <= 0 {
+				add(path+".viewport", "width and height must be positive, got %dx%d", vp.Width, vp.Height)
+			}
+			if vp.ScaleFactor < 0 {
+				add(path+".viewport.scale-factor", "must not be negative, got %g", vp.ScaleFactor)
+			}
+		}
+	}
+	for i, action := range c.Actions {
+		if err := action.Validate(); err != nil {
+			add(fmt.Sprintf("actions[%d]", i), "%s", strings.TrimPrefix(err.Error(), "screenshot: "))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+func validateHeaders(path string, headers map[string]string, add func(path, format string, args ...interface{})) {
+	for _, name := range sortedKeys(headers) {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			add(path, "invalid header name %q", name)
+		}
+		if strings.ContainsAny(headers[name], "\r\n") {
+			add(path+"."+name, "must not contain line breaks")
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ScreenshotOptions returns the options of the captures, including the
+// per-domain cookies, local storage and rules.
+func (c *Config) ScreenshotOptions() ([]ScreenshotOption, error) {
+	cp := c.Capture
+	opts := []ScreenshotOption{
+		PrintPDF(cp.PDF),
+		RawHTML(cp.HTML),
+		DumpHAR(cp.HAR),
+		AuditPerformance(cp.Performance),
+		AccessibilityTree(cp.AccessibilityTree),
+		DOMSnapshot(cp.DOMSnapshot),
+		Screencast(cp.Screencast),
+		MaxPageHeight(cp.MaxPageHeight),
+		MaxScrolls(cp.MaxScrolls),
+		AppendToFile(Files(cp.Files)),
+	}
+	if cp.ScreencastFrames {
+		opts = append(opts, ScreencastFrames(true))
+	}
+	if cp.Width > 0 {
+		opts = append(opts, Width(cp.Width))
+	}
+	if cp.Height > 0 {
+		opts = append(opts, Height(cp.Height))
+	}
+	if cp.Mobile {
+		opts = append(opts, Mobile(true))
+	}
+	if cp.ScaleFactor > 0 {
+		opts = append(opts, ScaleFactor(cp.ScaleFactor))
+	}
+	if cp.Width > 0 && cp.Height > 0 {
+		vp := &Viewport{Width: cp.Width, Height: cp.Height, Mobile: cp.Mobile, ScaleFactor: cp.ScaleFactor}
+		opts = append(opts, func(opts *ScreenshotOptions) {
+			opts.viewport = vp
+		})
+	}
+	if cp.Format != "" {
+		opts = append(opts, Format(cp.Format))
+	}
+	if cp.Quality > 0 {
+		opts = append(opts, Quality(cp.Quality))
+	}
+	if cp.MaxWidth > 0 {
+		opts = append(opts, MaxWidth(cp.MaxWidth))
+	}
+	if cp.MaxHeight > 0 {
+		opts = append(opts, MaxHeight(cp.MaxHeight))
+	}
+	if len(cp.SnapshotStyles) > 0 {
+		opts = append(opts, SnapshotStyles(cp.SnapshotStyles...))
+	}
+	if cp.FailOnStatus != "" {
+		ranges, err := ParseStatusRanges(cp.FailOnStatus)
+		if err != nil {
+			return nil, fmt.Errorf("config: capture.fail-on-status: %w", err)
+		}
+		opts = append(opts, FailOnStatus(ranges...))
+	}
+	if cp.Consent != "" {
+		mode, err := ParseConsentMode(cp.Consent)
+		if err != nil {
+			return nil, fmt.Errorf("config: capture.consent: %w", err)
+		}
+		opts = append(opts, Consent(mode))
+	}
+	opts = append(opts,
+		BeforeNavigate(cp.BeforeNavigate...),
+		AfterLoad(cp.AfterLoad...),
+		BeforeCapture(cp.BeforeCapture...),
+		InjectCSS(cp.CSS...),
+		HideSelectors(cp.Hide...),
+		RemoveSelectors(cp.Remove...),
+	)
+	if len(cp.Headers) > 0 {
+		opts = append(opts, Headers(cp.Headers))
+	}
+	if cp.ScrollStep > 0 {
+		opts = append(opts, ScrollStep(cp.ScrollStep))
+	}
+	if cp.ScrollInterval > 0 {
+		opts = append(opts, ScrollInterval(cp.ScrollInterval))
+	}
+	if cp.LoadMore != "" {
+		opts = append(opts, LoadMore(cp.LoadMore, cp.LoadMoreClicks))
+	}
+	if len(cp.Proxies) > 0 {
+		opts = append(opts, RotateProxy(NewProxyRotator(cp.Proxies...)))
+	}
+	if len(cp.ProxyBypass) > 0 {
+		opts = append(opts, ProxyBypass(cp.ProxyBypass...))
+	}
+
+	var cookies []Cookie
+	for _, domain := range sortedKeys(c.Cookies) {
+		for _, cookie := range c.Cookies[domain] {
+			if cookie.Domain == "" {
+				cookie.Domain = domain
+			}
+			cookies = append(cookies, cookie)
+		}
+	}
+	if len(cookies) > 0 {
+		opts = append(opts, Cookies(cookies))
+	}
+	if storage := storageItems(c.LocalStorage); len(storage) > 0 {
+		opts = append(opts, Storage(storage))
+	}
+	if storage := storageItems(c.SessionStorage); len(storage) > 0 {
+		opts = append(opts, SessionStorage(storage))
+	}
+	var idb []IndexedDBItem
+	for _, domain := range sortedKeys(c.IndexedDB) {
+		for _, item := range c.IndexedDB[domain] {
+			if item.Host == "" {
+				item.Host = domain
+			}
+			idb = append(idb, item)
+		}
+	}
+	if len(idb) > 0 {
+		opts = append(opts, IndexedDB(idb))
+	}
+	if len(c.Rules) > 0 {
+		opts = append(opts, WithRules(c.Rules))
+	}
+	if len(c.Actions) > 0 {
+		opts = append(opts, Actions(c.Actions...))
+	}
+	return opts, nil
+}
+
+func storageItems(storage map[string][]LocalStorage) (items []LocalStorage) {
+	for _, domain := range sortedKeys(storage) {
+		for _, item := range storage[domain] {
+			if item.Host == "" {
+				item.Host = domain
+			}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// LaunchOptions returns the options of the browser launched for each capture.
+func (c *Config) LaunchOptions() []LaunchOption {
+	b := c.Browser
+	var opts []LaunchOption
+	if b.ExecPath != "" {
+		opts = append(opts, ExecPath(b.ExecPath))
+	}
+	if b.Headless != nil {
+		opts = append(opts, Headless(*b.Headless))
+	}
+	if b.NoSandbox != nil {
+		opts = append(opts, NoSandbox(*b.NoSandbox))
+	}
+	if b.DisableGPU != nil {
+		opts = append(opts, DisableGPU(*b.DisableGPU))
+	}
+	if b.UserAgent != "" {
+		opts = append(opts, UserAgent(b.UserAgent))
+	}
+	if b.ProxyServer != "" {
+		opts = append(opts, ProxyServer(b.ProxyServer))
+	}
+	for name, value := range b.Flags {
+		opts = append(opts, Flag(strings.TrimLeft(name, "-"), value))
+	}
+	if len(b.RemoveFlags) > 0 {
+		opts = append(opts, RemoveFlags(b.RemoveFlags...))
+	}
+	if b.WindowWidth > 0 && b.WindowHeight > 0 {
+		opts = append(opts, WindowSize(b.WindowWidth, b.WindowHeight))
+	}
+	if len(b.Env) > 0 {
+		opts = append(opts, Env(b.Env...))
+	}
+	if b.UserDataDir != "" {
+		opts = append(opts, UserDataDir(b.UserDataDir))
+	}
+	if b.Profile != "" {
+		opts = append(opts, Profile(b.Profile))
+	}
+	if len(b.Extensions) > 0 {
+		opts = append(opts, Extensions(b.Extensions...))
+	}
+	if b.WSURLReadTimeout > 0 {
+		opts = append(opts, WSURLReadTimeout(b.WSURLReadTimeout))
+	}
+	return opts
+}
+
+// RemoteOptions returns the options of the remote browsers.
+func (c *Config) RemoteOptions() []RemoteOption {
+	var opts []RemoteOption
+	if c.Remote.HealthCheckInterval > 0 {
+		opts = append(opts, HealthCheckInterval(c.Remote.HealthCheckInterval))
+	}
+	if c.Remote.HealthCheckTimeout > 0 {
+		opts = append(opts, HealthCheckTimeout(c.Remote.HealthCheckTimeout))
+	}
+	if c.Remote.DisableHealthChecks {
+		opts = append(opts, DisableHealthChecks())
+	}
+	return opts
+}
@@ -0,0 +1,303 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// ConsentMode is how cookie consent banners are handled before capturing.
+type ConsentMode string
+
+const (
+	ConsentOff    ConsentMode = ""       // Leave the banners as is.
+	ConsentReject ConsentMode = "reject" // Reject all, falling back to hiding the banner.
+	ConsentAccept ConsentMode = "accept" // Accept all, falling back to hiding the banner.
+	ConsentHide   ConsentMode = "hide"   // Remove the banner without answering it.
+)
+
+// ParseConsentMode parses a consent mode, one of reject, accept, hide and off.
+func ParseConsentMode(s string) (ConsentMode, error) {
+	switch mode := ConsentMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case ConsentReject, ConsentAccept, ConsentHide:
+		return mode, nil
+	case ConsentOff, "off", "none":
+		return ConsentOff, nil
+	}
+	return ConsentOff, fmt.Errorf("screenshot: invalid consent mode %q", s)
+}
+
+// ConsentAction is what has been done with a consent banner.
+type ConsentAction string
+
+const (
+	ConsentRejected ConsentAction = "rejected"
+	ConsentAccepted ConsentAction = "accepted"
+	ConsentHidden   ConsentAction = "hidden"
+)
+
+// ConsentResult reports a consent banner dismissed before capturing.
+type ConsentResult struct {
+	CMP    string        `json:"cmp"`    // Consent management platform, e.g. OneTrust.
+	Action ConsentAction `json:"action"` // What has been done with the banner.
+	Frame  string        `json:"frame"`  // URL of the frame containing the banner.
+}
+
+// Consent handles the cookie consent banners of common consent management
+// platforms before capturing, see ConsentMode.
+func Consent(mode ConsentMode) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Consent = mode
+	}
+}
+
+// consentScript detects the banner of a consent management platform in the
+// document and clicks its reject or accept button by the mode. If there is no
+// such button, the banner is removed and the scrolling of the page restored.
+// Only visible banners are reported, an answered banner is usually hidden by
+// the platform itself.
+const consentScript = `(mode) => {
+  const cmps = [
+    { name: 'OneTrust', detect: '#onetrust-banner-sdk, #onetrust-pc-sdk',
+      reject: '#onetrust-reject-all-handler, .ot-pc-refuse-all-handler', accept: '#onetrust-accept-btn-handler, #accept-recommended-btn-handler',
+      overlay: '#onetrust-consent-sdk, .onetrust-pc-dark-filter' },
+    { name: 'Quantcast', detect: '#qc-cmp2-container, .qc-cmp2-container',
+      reject: '.qc-cmp2-summary-buttons button[mode="secondary"]', accept: '.qc-cmp2-summary-buttons button[mode="primary"]',
+      overlay: '#qc-cmp2-container, .qc-cmp2-container' },
+    { name: 'Didomi', detect: '#didomi-host, #didomi-popup, #didomi-notice',
+      reject: '#didomi-notice-disagree-button, .didomi-continue-without-agreeing', accept: '#didomi-notice-agree-button',
+      overlay: '#didomi-host, .didomi-popup-backdrop' },
+    { name: 'TrustArc', detect: '#truste-consent-track, #truste-consent-content, .truste_box_overlay',
+      reject: '#truste-consent-required', accept: '#truste-consent-button',
+      overlay: '#truste-consent-track, .truste_box_overlay, .truste_overlay' },
+    { name: 'TrustArc', detect: '.pdynamicbutton',
+      reject: '.pdynamicbutton .rejectAll', accept: '.pdynamicbutton .call' },
+    { name: 'Sourcepoint', detect: '.sp_choice_type_11, .sp_choice_type_13',
+      reject: '.sp_choice_type_13', accept: '.sp_choice_type_11' },
+    { name: 'Sourcepoint', detect: '[id^="sp_message_container"]',
+      overlay: '[id^="sp_message_container"]' },
+    { name: 'Cookiebot', detect: '#CybotCookiebotDialog',
+      reject: '#CybotCookiebotDialogBodyButtonDecline', accept: '#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll, #CybotCookiebotDialogBodyButtonAccept',
+      overlay: '#CybotCookiebotDialog, #CybotCookiebotDialogBodyUnderlay' },
+    { name: 'Usercentrics', shadow: '#usercentrics-root', detect: '[data-testid="uc-accept-all-button"]',
+      reject: '[data-testid="uc-deny-all-button"]', accept: '[data-testid="uc-accept-all-button"]',
+      overlay: '#usercentrics-root' },
+    { name: 'Osano', detect: '.osano-cm-window',
+      reject: '.osano-cm-denyAll', accept: '.osano-cm-accept-all, .osano-cm-acceptAll',
+      overlay: '.osano-cm-window' },
+    { name: 'Funding Choices', detect: '.fc-consent-root',
+      reject: '.fc-cta-do-not-consent', accept: '.fc-cta-consent',
+      overlay: '.fc-consent-root' },
+    { name: 'Complianz', detect: '.cmplz-cookiebanner',
+      reject: '.cmplz-cookiebanner .cmplz-deny', accept: '.cmplz-cookiebanner .cmplz-accept',
+      overlay: '.cmplz-cookiebanner' },
+    { name: 'consentmanager', detect: '#cmpbox',
+      reject: '.cmpboxbtnno', accept: '.cmpboxbtnyes',
+      overlay: '#cmpbox, #cmpbox2' },
+    { name: 'Klaro', detect: '.klaro .cookie-notice, .klaro .cookie-modal',
+      reject: '.klaro .cn-decline', accept: '.klaro .cm-btn-accept-all, .klaro .cm-btn-success',
+      overlay: '.klaro' },
+  ];
+  const visible = (el) => !!(el && (el.offsetWidth || el.offsetHeight || el.getClientRects().length));
+  const find = (root, sel) => {
+    try {
+      return Array.from(root.querySelectorAll(sel)).find(visible) || null;
+    } catch (_) {
+      return null;
+    }
+  };
+
+  for (const cmp of cmps) {
+    let root = document;
+    if (cmp.shadow) {
+      const host = document.querySelector(cmp.shadow);
+      root = host && host.shadowRoot;
+      if (!root) continue;
+    }
+    if (!find(root, cmp.detect)) continue;
+
+    const button = mode === 'accept' ? cmp.accept : mode === 'reject' ? cmp.reject : '';
+    const el = button && find(root, button);
+    if (el) {
+      el.click();
+      return [{ cmp: cmp.name, action: mode === 'accept' ? 'accepted' : 'rejected' }];
+    }
+    if (!cmp.overlay) continue;
+
+    let removed = 0;
+    document.querySelectorAll(cmp.overlay).forEach((el) => { el.remove(); removed++; });
+    if (removed > 0) {
+      for (const el of [document.documentElement, document.body]) {
+        if (el && getComputedStyle(el).overflow === 'hidden') el.style.setProperty('overflow', 'visible', 'important');
+      }
+      return [{ cmp: cmp.name, action: 'hidden' }];
+    }
+  }
+  return [];
+}`
+
+// frameTargets tracks the out-of-process iframes of a page, which are
+// attached automatically by chromedp.
+type frameTargets struct {
+	mu  sync.Mutex
+	ids map[target.SessionID]target.ID
+
+	// Contexts attached to the frames, shared by the consent passes.
+	ctxs    map[target.ID]context.Context
+	cancels []context.CancelFunc
+}
+
+func watchFrames(ctx context.Context) *frameTargets {
+	frames := &frameTargets{ids: make(map[target.SessionID]target.ID), ctxs: make(map[target.ID]context.Context)}
+	chromedp.ListenTarget(ctx, func(v interface{}) {
+		switch v := v.(type) {
+		case *target.EventAttachedToTarget:
+			if v.TargetInfo != nil && v.TargetInfo.Type == "iframe" {
+				frames.mu.Lock()
+				frames.ids[v.SessionID] = v.TargetInfo.TargetID
+				frames.mu.Unlock()
+			}
+		case *target.EventDetachedFromTarget:
+			frames.mu.Lock()
+			delete(frames.ctxs, frames.ids[v.SessionID])
+			delete(frames.ids, v.SessionID)
+			frames.mu.Unlock()
+		}
+	})
+	return frames
+}
+
+func (f *frameTargets) list() (ids []target.ID) {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range f.ids {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// context returns the context attached to the target of the frame, which is
+// created once per target so that the consent passes share its session.
+func (f *frameTargets) context(ctx context.Context, id target.ID) context.Context {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fctx, ok := f.ctxs[id]; ok {
+		return fctx
+	}
+	fctx, cancel := chromedp.NewContext(ctx, chromedp.WithTargetID(id))
+	f.ctxs[id] = fctx
+	f.cancels = append(f.cancels, cancel)
+	return fctx
+}
+
+// release detaches the contexts of the frames. Cancelling them also closes
+// the targets of the frames, so it is called once the page is captured.
+func (f *frameTargets) release() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	cancels := f.cancels
+	f.ctxs = make(map[target.ID]context.Context)
+	f.cancels = nil
+	f.mu.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// handleConsent dismisses the consent banners in the frames of the page, the
+// out-of-process frames first since their banners are usually overlaid by the
+// page. After clicking a button, it waits for the platform to close the banner
+// and removes the banners left.
+func handleConsent(results *[]ConsentResult, frames *frameTargets, options ScreenshotOptions) chromedp.Action {
+	if options.Consent == ConsentOff {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		clicked, err := dismissConsent(ctx, results, frames, options.Consent)
+		if err != nil || !clicked || options.Consent == ConsentHide {
+			return err
+		}
+		if err := chromedp.Sleep(time.Second).Do(ctx); err != nil {
+			return err
+		}
+		_, err = dismissConsent(ctx, results, frames, ConsentHide)
+		return err
+	})
+}
+
+func dismissConsent(ctx context.Context, results *[]ConsentResult, frames *frameTargets, mode ConsentMode) (clicked bool, err error) {
+	arg, err := json.Marshal(mode)
+	if err != nil {
+		return false, err
+	}
+	expr := fmt.Sprintf("(%s)(%s)", consentScript, arg)
+
+	report := func(found []ConsentResult, frame string) {
+		for _, res := range found {
+			res.Frame = frame
+			*results = append(*results, res)
+			clicked = clicked || res.Action != ConsentHidden
+		}
+	}
+
+	for _, id := range frames.list() {
+		fctx := frames.context(ctx, id)
+		var found []ConsentResult
+		var frame string
+		if err := chromedp.Run(fctx, chromedp.Evaluate(expr, &found), chromedp.Location(&frame)); err != nil {
+			if ctx.Err() != nil {
+				return clicked, ctx.Err()
+			}
+			continue
+		}
+		report(found, frame)
+	}
+
+	tree, err := page.GetFrameTree().Do(ctx)
+	if err != nil {
+		return clicked, err
+	}
+	// Child frames first, the main frame last. Scripts are evaluated in
+	// isolated worlds so that the scripts of the page cannot interfere.
+	var walk func(*page.FrameTree) error
+	walk = func(node *page.FrameTree) error {
+		for _, child := range node.ChildFrames {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		world, err := page.CreateIsolatedWorld(node.Frame.ID).Do(ctx)
+		if err != nil {
+			// The frame may be detached or out-of-process.
+			return ctx.Err()
+		}
+		var found []ConsentResult
+		err = chromedp.Evaluate(expr, &found, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithContextID(world)
+		}).Do(ctx)
+		if err != nil {
+			return ctx.Err()
+		}
+		report(found, node.Frame.URL)
+		return nil
+	}
+	return clicked, walk(tree)
+}
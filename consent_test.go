@@ -0,0 +1,92 @@
+package screenshot
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wabarc/helper"
+)
+
+func TestParseConsentMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    ConsentMode
+		invalid bool
+	}{
+		{mode: "reject", want: ConsentReject},
+		{mode: " Accept ", want: ConsentAccept},
+		{mode: "hide", want: ConsentHide},
+		{mode: "off", want: ConsentOff},
+		{mode: "", want: ConsentOff},
+		{mode: "dismiss", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			mode, err := ParseConsentMode(test.mode)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("unexpected parse %q without error", test.mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != test.want {
+				t.Errorf("unexpected mode, got %q instead of %q", mode, test.want)
+			}
+		})
+	}
+}
+
+func TestScreenshotWithConsent(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body style="overflow: hidden">
+<div id="onetrust-consent-sdk">
+  <div id="onetrust-banner-sdk">
+    We use cookies
+    <button id="onetrust-accept-btn-handler">Accept</button>
+    <button id="onetrust-reject-all-handler" onclick="document.getElementById('onetrust-consent-sdk').remove()">Reject</button>
+  </div>
+</div>
+<h1>Example Domain</h1>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, RawHTML(true), Consent(ConsentReject))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shot.Consent) != 1 {
+		t.Fatalf("unexpected consent results: %+v", shot.Consent)
+	}
+	if res := shot.Consent[0]; res.CMP != "OneTrust" || res.Action != ConsentRejected {
+		t.Errorf("unexpected consent result: %+v", res)
+	}
+	if strings.Contains(string(shot.HTML), "We use cookies") {
+		t.Error("unexpected consent banner in html")
+	}
+}
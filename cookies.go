@@ -0,0 +1,299 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+// ExportCookies returns the cookies of the browser after the capture in
+// Screenshots.Cookies, so that the session can be saved and reused, see
+// ExportNetscapeCookies and ExportJSONCookies.
+func ExportCookies(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.ExportCookies = b
+	}
+}
+
+// LoadCookies imports cookies in any of the supported formats, which is
+// detected from the content: JSON, Netscape cookies.txt, or the yaml
+// configuration of ImportCookies.
+func LoadCookies(r []byte) ([]Cookie, error) {
+	trimmed := bytes.TrimSpace(r)
+	switch {
+	case len(trimmed) == 0:
+		return nil, nil
+	case trimmed[0] == '[' || trimmed[0] == '{':
+		return ImportJSONCookies(r)
+	case isNetscapeCookies(trimmed):
+		return ImportNetscapeCookies(r)
+	}
+	return ImportCookies(r)
+}
+
+func isNetscapeCookies(r []byte) bool {
+	line := r
+	if i := bytes.IndexByte(r, '\n'); i >= 0 {
+		line = r[:i]
+	}
+	return bytes.HasPrefix(line, []byte("# Netscape HTTP Cookie File")) ||
+		bytes.HasPrefix(line, []byte("# HTTP Cookie File")) ||
+		bytes.HasPrefix(line, []byte(httpOnlyPrefix)) ||
+		bytes.Count(line, []byte("\t")) >= 6
+}
+
+// ImportNetscapeCookies imports cookies in the Netscape cookies.txt format
+// exported by browser extensions, curl and wget. Each line has the tab
+// separated fields: domain, include subdomains, path, secure, expires as
+// seconds since the UNIX epoch (0 for a session cookie), name and value.
+// A domain prefixed with #HttpOnly_ is an http-only cookie.
+func ImportNetscapeCookies(r []byte) (cookies []Cookie, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(r))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		var cookie Cookie
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+			cookie.HTTPOnly = true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			// A cookie without value.
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies.txt line %d: want 7 tab separated fields, got %d", n, len(fields))
+		}
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies.txt line %d: invalid expires %q", n, fields[4])
+		}
+		cookie.Domain = fields[0]
+		cookie.Path = fields[2]
+		cookie.Secure = strings.EqualFold(fields[3], "TRUE")
+		cookie.Expires = epochTime(expires)
+		cookie.Name = fields[5]
+		cookie.Value = fields[6]
+		if strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(cookie.Domain, ".") {
+			cookie.Domain = "." + cookie.Domain
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, scanner.Err()
+}
+
+// jsonCookie is a cookie exported by browser extensions such as
+// EditThisCookie, or by the DevTools protocol.
+type jsonCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain"`
+	Path     string `json:"path,omitempty"`
+	HostOnly bool   `json:"hostOnly,omitempty"`
+	HTTPOnly bool   `json:"httpOnly"`
+	Secure   bool   `json:"secure"`
+	Session  bool   `json:"session"`
+	SameSite string `json:"sameSite,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	Size     int    `json:"size,omitempty"`
+
+	// Seconds since the UNIX epoch, in the DevTools protocol and EditThisCookie.
+	Expires        *float64 `json:"expires,omitempty"`
+	ExpirationDate *float64 `json:"expirationDate,omitempty"`
+}
+
+// ImportJSONCookies imports cookies in JSON, as an array of cookies exported
+// by browser extensions such as EditThisCookie, or of the DevTools protocol
+// such as the result of Network.getAllCookies, which may be wrapped in an
+// object with a cookies field.
+func ImportJSONCookies(r []byte) ([]Cookie, error) {
+	var items []jsonCookie
+	if trimmed := bytes.TrimSpace(r); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil {
+			return nil, err
+		}
+		items = wrapped.Cookies
+	} else if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, err
+	}
+
+	cookies := make([]Cookie, 0, len(items))
+	for i, item := range items {
+		if item.Name == "" {
+			return nil, fmt.Errorf("cookie %d: missing name", i+1)
+		}
+		cookie := Cookie{
+			Name:     item.Name,
+			Value:    item.Value,
+			Domain:   item.Domain,
+			Path:     item.Path,
+			Size:     item.Size,
+			HTTPOnly: item.HTTPOnly,
+			Secure:   item.Secure,
+			SameSite: normalizeSameSite(item.SameSite),
+			Priority: item.Priority,
+		}
+		if !item.Session {
+			switch {
+			case item.Expires != nil:
+				cookie.Expires = epochTime(*item.Expires)
+			case item.ExpirationDate != nil:
+				cookie.Expires = epochTime(*item.ExpirationDate)
+			}
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// normalizeSameSite maps the SameSite values of browser extensions, such as
+// no_restriction, to the ones of the DevTools protocol.
+func normalizeSameSite(s string) string {
+	switch strings.ToLower(s) {
+	case "strict":
+		return string(network.CookieSameSiteStrict)
+	case "lax":
+		return string(network.CookieSameSiteLax)
+	case "none", "no_restriction":
+		return string(network.CookieSameSiteNone)
+	}
+	return ""
+}
+
+// ExportNetscapeCookies exports the cookies in the Netscape cookies.txt
+// format, see ImportNetscapeCookies.
+func ExportNetscapeCookies(cookies []Cookie) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# Netscape HTTP Cookie File\n")
+	for _, cookie := range cookies {
+		domain := cookie.Domain
+		if cookie.HTTPOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !cookie.Expires.IsZero() {
+			expires = cookie.Expires.Unix()
+		}
+		fmt.Fprintf(&buf, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(cookie.Domain, ".")),
+			pathOrRoot(cookie.Path),
+			netscapeBool(cookie.Secure),
+			expires,
+			cookie.Name,
+			cookie.Value,
+		)
+	}
+	return buf.Bytes()
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// ExportJSONCookies exports the cookies as a JSON array in the format of the
+// DevTools protocol, see ImportJSONCookies.
+func ExportJSONCookies(cookies []Cookie) ([]byte, error) {
+	items := make([]jsonCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		expires := float64(-1)
+		if !cookie.Expires.IsZero() {
+			expires = float64(cookie.Expires.UnixNano()) / float64(time.Second)
+		}
+		items = append(items, jsonCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     pathOrRoot(cookie.Path),
+			HTTPOnly: cookie.HTTPOnly,
+			Secure:   cookie.Secure,
+			Session:  cookie.Expires.IsZero(),
+			SameSite: cookie.SameSite,
+			Priority: cookie.Priority,
+			Size:     cookie.Size,
+			Expires:  &expires,
+		})
+	}
+	return json.MarshalIndent(items, "", "  ")
+}
+
+// epochTime returns the time of the seconds since the UNIX epoch, or the
+// zero time of a session cookie.
+func epochTime(sec float64) time.Time {
+	if sec <= 0 || math.IsNaN(sec) || math.IsInf(sec, 0) {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))).UTC()
+}
+
+// cookieOf converts the cookie of the DevTools protocol.
+func cookieOf(c *network.Cookie) Cookie {
+	cookie := Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Size:     int(c.Size),
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+		SameSite: string(c.SameSite),
+		Priority: string(c.Priority),
+	}
+	if !c.Session {
+		cookie.Expires = epochTime(c.Expires)
+	}
+	return cookie
+}
+
+// exportCookies reads the cookie jar of the browser context of the capture,
+// which is not the default one on remote browsers.
+func exportCookies(cookies *[]Cookie, options ScreenshotOptions) chromedp.Action {
+	if !options.ExportCookies {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		jar, err := storage.GetCookies().WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range jar {
+			*cookies = append(*cookies, cookieOf(c))
+		}
+		return nil
+	})
+}
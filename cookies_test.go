@@ -0,0 +1,116 @@
+package screenshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestImportNetscapeCookies(t *testing.T) {
+	f := "# Netscape HTTP Cookie File\n" +
+		"# https://curl.se/docs/http-cookies.html\n" +
+		"\n" +
+		".example.com\tTRUE\t/\tTRUE\t1893456000\tfoo\tbar\n" +
+		"#HttpOnly_example.org\tFALSE\t/app\tFALSE\t0\tsid\tabc=def\r\n" +
+		"example.net\tFALSE\t/\tFALSE\t0\tempty\n"
+	cookies, err := LoadCookies(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cookies); n != 3 {
+		t.Fatalf("unexpected number of cookies got %d instead of %d", n, 3)
+	}
+	if c := cookies[0]; c.Domain != ".example.com" || !c.Secure || c.Expires.Unix() != 1893456000 || c.Value != "bar" {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+	if c := cookies[1]; c.Domain != "example.org" || !c.HTTPOnly || !c.Expires.IsZero() || c.Value != "abc=def" || c.Path != "/app" {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+	if c := cookies[2]; c.Name != "empty" || c.Value != "" {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+
+	if _, err := ImportNetscapeCookies(Byte("example.com\tTRUE\t/\n")); err == nil {
+		t.Error("unexpected nil error of malformed line")
+	}
+}
+
+func TestImportJSONCookies(t *testing.T) {
+	// EditThisCookie format.
+	f := `[{"domain": ".example.com", "expirationDate": 1893456000.5, "hostOnly": false, "httpOnly": true,
+  "name": "foo", "path": "/", "sameSite": "no_restriction", "secure": true, "session": false, "value": "bar", "id": 1},
+ {"domain": "example.org", "name": "sid", "value": "1", "session": true, "sameSite": "unspecified"}]`
+	cookies, err := LoadCookies(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cookies); n != 2 {
+		t.Fatalf("unexpected number of cookies got %d instead of %d", n, 2)
+	}
+	if c := cookies[0]; c.SameSite != "None" || !c.HTTPOnly || c.Expires.Unix() != 1893456000 {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+	if c := cookies[1]; c.SameSite != "" || !c.Expires.IsZero() {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+
+	// DevTools protocol format wrapped in an object.
+	f = `{"cookies": [{"name": "foo", "value": "bar", "domain": "example.com", "path": "/", "expires": -1,
+  "size": 6, "httpOnly": false, "secure": false, "session": true, "sameSite": "Lax", "priority": "Medium"}]}`
+	cookies, err = LoadCookies(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].SameSite != "Lax" || !cookies[0].Expires.IsZero() {
+		t.Errorf("unexpected cookies got %+v", cookies)
+	}
+}
+
+func TestLoadCookiesYAML(t *testing.T) {
+	f := `cookies:
+  example.com:
+    - name: 'foo'
+      value: 'bar'`
+	cookies, err := LoadCookies(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 1 || cookies[0].Domain != "example.com" {
+		t.Errorf("unexpected cookies got %+v", cookies)
+	}
+}
+
+func TestExportCookies(t *testing.T) {
+	cookies := []Cookie{
+		{Name: "foo", Value: "bar", Domain: ".example.com", Path: "/", Secure: true, Expires: time.Unix(1893456000, 0)},
+		{Name: "sid", Value: "1", Domain: "example.org", HTTPOnly: true, SameSite: "Lax"},
+	}
+
+	got, err := ImportNetscapeCookies(ExportNetscapeCookies(cookies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Domain != ".example.com" || !got[0].Expires.Equal(cookies[0].Expires) || !got[1].HTTPOnly || got[1].Path != "/" {
+		t.Errorf("unexpected cookies of cookies.txt got %+v", got)
+	}
+
+	buf, err := ExportJSONCookies(cookies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ImportJSONCookies(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Expires.Equal(cookies[0].Expires) || !got[1].Expires.IsZero() || got[1].SameSite != "Lax" {
+		t.Errorf("unexpected cookies of JSON got %+v", got)
+	}
+}
+
+func TestCookieOf(t *testing.T) {
+	c := cookieOf(&network.Cookie{Name: "foo", Value: "bar", Domain: "example.com", Expires: -1, Session: true, SameSite: network.CookieSameSiteStrict})
+	if c.Name != "foo" || !c.Expires.IsZero() || c.SameSite != "Strict" {
+		t.Errorf("unexpected cookie got %+v", c)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/domsnapshot"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultSnapshotStyles is the set of computed styles captured by DOMSnapshot
+// if no styles are specified by SnapshotStyles.
+var DefaultSnapshotStyles = []string{
+	"display",
+	"visibility",
+	"position",
+	"z-index",
+	"overflow",
+	"opacity",
+	"color",
+	"background-color",
+	"background-image",
+	"font-family",
+	"font-size",
+	"font-weight",
+}
+
+// PageSnapshot represents the DOM tree of a page with layout and computed
+// styles, decoded from the string table of DOMSnapshot.captureSnapshot.
+type PageSnapshot struct {
+	Documents []SnapshotDocument `json:"documents"`
+}
+
+// SnapshotDocument represents a document of the page, the first one is the
+// main document and the rest are documents of frames.
+type SnapshotDocument struct {
+	URL           string         `json:"url"`
+	Title         string         `json:"title,omitempty"`
+	ContentWidth  float64        `json:"contentWidth,omitempty"`
+	ContentHeight float64        `json:"contentHeight,omitempty"`
+	Nodes         []SnapshotNode `json:"nodes"`
+}
+
+// SnapshotNode represents a DOM node, parent is the index of its parent node
+// in the document, -1 for the root.
+type SnapshotNode struct {
+	Parent int64             `json:"parent"`
+	Type   int64             `json:"type"`
+	Name   string            `json:"name,omitempty"`
+	Value  string            `json:"value,omitempty"`
+	Attrs  map[string]string `json:"attrs,omitempty"`
+	Bounds []float64         `json:"bounds,omitempty"` // x, y, width, height
+	Styles map[string]string `json:"styles,omitempty"`
+	Text   string            `json:"text,omitempty"`
+
+	// Index of the document of the frame owned by this node, if any.
+	ContentDocument *int64 `json:"contentDocument,omitempty"`
+}
+
+func captureDOMSnapshot[T As](res *T, options ScreenshotOptions) chromedp.Action {
+	if !options.DOMSnapshot {
+		return chromedp.Tasks{}
+	}
+
+	styles := options.SnapshotStyles
+	if len(styles) == 0 {
+		styles = DefaultSnapshotStyles
+	}
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			documents, strs, err := domsnapshot.CaptureSnapshot(styles).Do(ctx)
+			if err != nil {
+				return err
+			}
+			buf, err := json.Marshal(decodeSnapshot(documents, strs, styles))
+			if err != nil {
+				return err
+			}
+			return setResult(res, buf, options.Files.DOM)
+		}),
+	}
+}
+
+func decodeSnapshot(documents []*domsnapshot.DocumentSnapshot, strs []string, styles []string) *PageSnapshot {
+	str := func(i domsnapshot.StringIndex) string {
+		if i < 0 || int(i) >= len(strs) {
+			return ""
+		}
+		return strs[i]
+	}
+
+	snapshot := &PageSnapshot{Documents: make([]SnapshotDocument, 0, len(documents))}
+	for _, d := range documents {
+		doc := SnapshotDocument{
+			URL:           str(d.DocumentURL),
+			Title:         str(d.Title),
+			ContentWidth:  d.ContentWidth,
+			ContentHeight: d.ContentHeight,
+		}
+		if d.Nodes == nil {
+			snapshot.Documents = append(snapshot.Documents, doc)
+			continue
+		}
+
+		nodes := d.Nodes
+		doc.Nodes = make([]SnapshotNode, len(nodes.NodeType))
+		for i := range doc.Nodes {
+			n := &doc.Nodes[i]
+			n.Parent = -1
+			if i < len(nodes.ParentIndex) {
+				n.Parent = nodes.ParentIndex[i]
+			}
+			n.Type = nodes.NodeType[i]
+			if i < len(nodes.NodeName) {
+				n.Name = str(nodes.NodeName[i])
+			}
+			if i < len(nodes.NodeValue) {
+				n.Value = str(nodes.NodeValue[i])
+			}
+			if i < len(nodes.Attributes) && len(nodes.Attributes[i]) > 1 {
+				attrs := nodes.Attributes[i]
+				n.Attrs = make(map[string]string, len(attrs)/2)
+				for j := 0; j+1 < len(attrs); j += 2 {
+					n.Attrs[str(domsnapshot.StringIndex(attrs[j]))] = str(domsnapshot.StringIndex(attrs[j+1]))
+				}
+			}
+		}
+		if nodes.ContentDocumentIndex != nil {
+			for j, i := range nodes.ContentDocumentIndex.Index {
+				if int(i) < len(doc.Nodes) && j < len(nodes.ContentDocumentIndex.Value) {
+					v := nodes.ContentDocumentIndex.Value[j]
+					doc.Nodes[i].ContentDocument = &v
+				}
+			}
+		}
+
+		if layout := d.Layout; layout != nil {
+			for j, i := range layout.NodeIndex {
+				if int(i) >= len(doc.Nodes) {
+					continue
+				}
+				n := &doc.Nodes[i]
+				if j < len(layout.Bounds) {
+					n.Bounds = layout.Bounds[j]
+				}
+				if j < len(layout.Text) {
+					n.Text = str(layout.Text[j])
+				}
+				if j < len(layout.Styles) {
+					for k, v := range layout.Styles[j] {
+						if k >= len(styles) {
+							break
+						}
+						if n.Styles == nil {
+							n.Styles = make(map[string]string, len(styles))
+						}
+						n.Styles[styles[k]] = str(domsnapshot.StringIndex(v))
+					}
+				}
+			}
+		}
+		snapshot.Documents = append(snapshot.Documents, doc)
+	}
+
+	return snapshot
+}
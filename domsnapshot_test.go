@@ -0,0 +1,52 @@
+package screenshot
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/domsnapshot"
+)
+
+func TestDecodeSnapshot(t *testing.T) {
+	strs := []string{"http://example.com/", "Example Domain", "#document", "HTML", "DIV", "class", "main", "block", "rgb(0, 0, 0)", "Hello"}
+	documents := []*domsnapshot.DocumentSnapshot{
+		{
+			DocumentURL: 0,
+			Title:       1,
+			Nodes: &domsnapshot.NodeTreeSnapshot{
+				ParentIndex: []int64{-1, 0, 1},
+				NodeType:    []int64{9, 1, 1},
+				NodeName:    []domsnapshot.StringIndex{2, 3, 4},
+				NodeValue:   []domsnapshot.StringIndex{-1, -1, -1},
+				Attributes:  []domsnapshot.ArrayOfStrings{{}, {}, {5, 6}},
+			},
+			Layout: &domsnapshot.LayoutTreeSnapshot{
+				NodeIndex: []int64{2},
+				Styles:    []domsnapshot.ArrayOfStrings{{7, 8}},
+				Bounds:    []domsnapshot.Rectangle{{0, 0, 100, 20}},
+				Text:      []domsnapshot.StringIndex{9},
+			},
+		},
+	}
+
+	snapshot := decodeSnapshot(documents, strs, []string{"display", "color"})
+	if len(snapshot.Documents) != 1 {
+		t.Fatalf("unexpected number of documents got %d instead of %d", len(snapshot.Documents), 1)
+	}
+	doc := snapshot.Documents[0]
+	if doc.URL != "http://example.com/" || doc.Title != "Example Domain" {
+		t.Errorf("unexpected document got url %s title %s", doc.URL, doc.Title)
+	}
+	if len(doc.Nodes) != 3 {
+		t.Fatalf("unexpected number of nodes got %d instead of %d", len(doc.Nodes), 3)
+	}
+	div := doc.Nodes[2]
+	if div.Name != "DIV" || div.Parent != 1 || div.Attrs["class"] != "main" {
+		t.Errorf("unexpected node got %+v", div)
+	}
+	if div.Styles["display"] != "block" || div.Styles["color"] != "rgb(0, 0, 0)" {
+		t.Errorf("unexpected computed styles got %v", div.Styles)
+	}
+	if len(div.Bounds) != 4 || div.Bounds[2] != 100 || div.Text != "Hello" {
+		t.Errorf("unexpected layout got bounds %v text %s", div.Bounds, div.Text)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+var (
+	// ErrBrowserCrashed is returned if the page or the browser crashed during the capture.
+	ErrBrowserCrashed = errors.New("screenshot: browser crashed")
+
+	// ErrTimeout matches a StageError caused by a timeout, use errors.Is to check it.
+	ErrTimeout = errors.New("screenshot: timeout")
+)
+
+// Stage is a named stage of a capture.
+type Stage string
+
+const (
+	StageSetup         Stage = "setup"
+	StageNavigate      Stage = "navigate"
+	StageConsent       Stage = "consent"
+	StageActions       Stage = "actions"
+	StageScroll        Stage = "scroll"
+	StageBeforeCapture Stage = "before-capture"
+	StageScreencast    Stage = "screencast"
+	StageTitle         Stage = "title"
+	StagePerformance   Stage = "performance"
+	StageScreenshot    Stage = "screenshot"
+	StageAccessibility Stage = "accessibility"
+	StageHTML          Stage = "html"
+	StageDOMSnapshot   Stage = "dom-snapshot"
+	StagePDF           Stage = "pdf"
+	StageCookies       Stage = "cookies"
+	StageHAR           Stage = "har"
+)
+
+// NavigationError is returned if the browser failed to navigate to the page,
+// the code is the network error reported by Chrome, e.g. net::ERR_NAME_NOT_RESOLVED.
+type NavigationError struct {
+	URL  string
+	Code string
+}
+
+func (e *NavigationError) Error() string {
+	return fmt.Sprintf("screenshot: navigate to %s failed: %s", e.URL, e.Code)
+}
+
+// HTTPStatusError is returned if the main document responded with a rejected HTTP status.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("screenshot: %s responded with status %d", e.URL, e.StatusCode)
+}
+
+// ArtifactError is returned if an artifact could not be written to file.
+type ArtifactError struct {
+	Path string
+	Err  error
+}
+
+func (e *ArtifactError) Error() string {
+	return fmt.Sprintf("screenshot: write artifact %s failed: %v", e.Path, e.Err)
+}
+
+func (e *ArtifactError) Unwrap() error {
+	return e.Err
+}
+
+// StageError represents a failure during a named stage of the capture.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("screenshot: %s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrTimeout and the stage timed out.
+func (e *StageError) Is(target error) bool {
+	return target == ErrTimeout && e.Timeout()
+}
+
+// Timeout reports whether the stage failed due to a timeout.
+func (e *StageError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded) || errors.Is(e.Err, chromedp.ErrPollingTimeout)
+}
+
+// CaptureError describes the stages that failed during a capture, the
+// artifacts of the other stages are returned alongside it.
+type CaptureError struct {
+	Errors []error
+}
+
+func (e *CaptureError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any error of the failed stages matches the target.
+func (e *CaptureError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the failed stages that matches the target,
+// and if so, sets the target to that error value and returns true.
+func (e *CaptureError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stages returns the names of the failed stages.
+func (e *CaptureError) Stages() (stages []Stage) {
+	for _, err := range e.Errors {
+		var se *StageError
+		if errors.As(err, &se) {
+			stages = append(stages, se.Stage)
+		}
+	}
+	return stages
+}
@@ -0,0 +1,72 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestCaptureError(t *testing.T) {
+	err := error(&CaptureError{Errors: []error{
+		&StageError{Stage: StageScroll, Err: chromedp.ErrPollingTimeout},
+		&StageError{Stage: StagePDF, Err: &ArtifactError{Path: "pdf.pdf", Err: os.ErrPermission}},
+		&StageError{Stage: StageHTML, Err: fmt.Errorf("%w: %v", ErrBrowserCrashed, context.Canceled)},
+	}})
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Error("unexpected capture error does not match ErrTimeout")
+	}
+	if !errors.Is(err, ErrBrowserCrashed) {
+		t.Error("unexpected capture error does not match ErrBrowserCrashed")
+	}
+	if !errors.Is(err, os.ErrPermission) {
+		t.Error("unexpected capture error does not match os.ErrPermission")
+	}
+
+	var artifactErr *ArtifactError
+	if !errors.As(err, &artifactErr) || artifactErr.Path != "pdf.pdf" {
+		t.Errorf("unexpected artifact error got %v", artifactErr)
+	}
+
+	var captureErr *CaptureError
+	if !errors.As(err, &captureErr) {
+		t.Fatal("unexpected capture error type")
+	}
+	if stages := captureErr.Stages(); len(stages) != 3 || stages[0] != StageScroll {
+		t.Errorf("unexpected failed stages got %v", stages)
+	}
+
+	stageErr := &StageError{Stage: StageHTML, Err: context.Canceled}
+	if errors.Is(stageErr, ErrTimeout) {
+		t.Error("unexpected canceled stage matches ErrTimeout")
+	}
+}
+
+func TestSetResultArtifactError(t *testing.T) {
+	dirname, err := os.MkdirTemp(os.TempDir(), "screenshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirname)
+
+	var res Path
+	file := path.Join(dirname, "image.png")
+	if err := setResult(&res, []byte("data"), file); err != nil {
+		t.Fatal(err)
+	}
+	if res.String() != file {
+		t.Errorf("unexpected result got %s instead of %s", res, file)
+	}
+
+	// Use a regular file as directory to fail writing
+	err = setResult(&res, []byte("data"), path.Join(file, "image.png"))
+	var artifactErr *ArtifactError
+	if !errors.As(err, &artifactErr) {
+		t.Fatalf("unexpected error got %v instead of ArtifactError", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"os"
+	"time"
+)
+
+// Event is emitted during a capture to the callback registered by OnEvent.
+// It is one of BrowserAllocated, NavigationStarted, LifecycleEvent,
+// ScrollProgress, ArtifactProduced and Finished.
+type Event interface {
+	event()
+}
+
+// BrowserAllocated is emitted once the browser tab of the capture is ready.
+type BrowserAllocated struct {
+	URL string
+}
+
+// NavigationStarted is emitted before navigating to the page.
+type NavigationStarted struct {
+	URL string
+}
+
+// LifecycleEvent is emitted for each lifecycle event of the main frame,
+// such as DOMContentLoaded, load and networkAlmostIdle.
+type LifecycleEvent struct {
+	URL  string
+	Name string
+}
+
+// ScrollProgress is emitted after each scroll step, the offset is the bottom
+// of the viewport and the height is the current height of the page in pixels.
+type ScrollProgress struct {
+	URL    string
+	Offset float64
+	Height float64
+}
+
+// ArtifactProduced is emitted when a stage produced an artifact, the size is in bytes.
+type ArtifactProduced struct {
+	URL   string
+	Stage Stage
+	Size  int64
+}
+
+// Finished is emitted when the capture finished, err is the error returned by the capture.
+type Finished struct {
+	URL      string
+	Duration time.Duration
+	Err      error
+}
+
+func (BrowserAllocated) event()  {}
+func (NavigationStarted) event() {}
+func (LifecycleEvent) event()    {}
+func (ScrollProgress) event()    {}
+func (ArtifactProduced) event()  {}
+func (Finished) event()          {}
+
+// OnEvent registers a callback receiving the events of a capture. The callback
+// may be called concurrently and should return quickly.
+func OnEvent(fn func(Event)) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.OnEvent = fn
+	}
+}
+
+func (opts ScreenshotOptions) emit(ev Event) {
+	if opts.OnEvent != nil {
+		opts.OnEvent(ev)
+	}
+}
+
+// artifactSize returns the size of the artifact in bytes, zero if absent.
+func artifactSize[T As](v T) int64 {
+	switch t := (interface{})(v).(type) {
+	case Byte:
+		return int64(len(t))
+	case Path:
+		if t == "" {
+			return 0
+		}
+		if fi, err := os.Stat(string(t)); err == nil {
+			return fi.Size()
+		}
+	}
+	return 0
+}
@@ -15,7 +15,6 @@ import (
 
 	"github.com/chromedp/cdproto/har"
 	"github.com/chromedp/cdproto/network"
-	"github.com/wabarc/helper"
 )
 
 // copied from https://github.com/chromedp/chromedp/issues/42#issuecomment-500191682
@@ -245,14 +244,5 @@ func compose[T As](requestsID []network.RequestID, mRequests, mResponses *sync.M
 		return err
 	}
 
-	switch t := (interface{})(res).(type) {
-	case *Byte:
-		*t = buf
-	case *Path:
-		err = helper.WriteFile(options.Files.HAR, buf, perm)
-		if err == nil {
-			*t = Path(options.Files.HAR)
-		}
-	}
-	return err
+	return setResult(res, buf, options.Files.HAR)
 }
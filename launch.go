@@ -0,0 +1,302 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/wabarc/helper"
+)
+
+// LaunchOptions is the options of the browser launched for each capture by a
+// Screenshoter created by NewChromeScreenshoter.
+type LaunchOptions struct {
+	ExecPath   string // Path to the browser executable, found automatically if empty.
+	Headless   bool
+	NoSandbox  bool
+	DisableGPU bool
+	UserAgent  string
+	// Proxy server of the browser, e.g. socks5://127.0.0.1:1080.
+	ProxyServer string
+
+	// Extra command line flags of the browser, such as {"lang": "de-DE"}.
+	// A true value passes the flag without value.
+	Flags map[string]interface{}
+	// Flags removed from the default ones, such as disable-web-security.
+	RemoveFlags []string
+
+	WindowWidth  int
+	WindowHeight int
+
+	// Environment variables of the browser process, as KEY=value.
+	Env []string
+	// User data directory of the browser, a temporary one removed after
+	// the capture is used if empty.
+	UserDataDir string
+	// Persistent user data directory used by the captures in turn, see Profile.
+	Profile string
+	// Directories of unpacked extensions loaded by the browser.
+	Extensions []string
+
+	// Timeout of reading the websocket URL from the browser output.
+	WSURLReadTimeout time.Duration
+}
+
+type LaunchOption func(*LaunchOptions)
+
+// Flags of the browser besides the defaults of chromedp.
+var defaultLaunchFlags = map[string]interface{}{
+	"ignore-certificate-errors":      true,
+	"allow-running-insecure-content": true,
+	"no-default-browser-check":       true,
+	"disable-notifications":          true,
+	"disable-web-security":           true,
+	"disable-webgl":                  true,
+	"no-first-run":                   true,
+}
+
+// DefaultLaunchOptions returns the launch options used by Screenshot, which
+// are configured by the environment variables CHROMEDP_NO_HEADLESS,
+// CHROMEDP_NO_SANDBOX, CHROMEDP_USER_AGENT, CHROMEDP_WSURLREADTIMEOUT and
+// the proxy variables such as PROXY_SERVER.
+func DefaultLaunchOptions() LaunchOptions {
+	f := "false"
+	opts := LaunchOptions{
+		Headless:         true,
+		DisableGPU:       true,
+		UserAgent:        defaultUA,
+		ProxyServer:      proxyServer(),
+		WSURLReadTimeout: wsURLReadTimeout(),
+	}
+	if noHeadless := os.Getenv("CHROMEDP_NO_HEADLESS"); noHeadless != "" && noHeadless != f {
+		opts.Headless = false
+	}
+	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "" && noSandbox != f {
+		opts.NoSandbox = true
+	}
+	if userAgent := os.Getenv("CHROMEDP_USER_AGENT"); userAgent != "" {
+		opts.UserAgent = userAgent
+	}
+	return opts
+}
+
+// ExecPath sets the path to the browser executable.
+func ExecPath(path string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.ExecPath = path
+	}
+}
+
+// Headless runs the browser without window.
+func Headless(b bool) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.Headless = b
+	}
+}
+
+// NoSandbox disables the sandbox of the browser, e.g. running as root in a container.
+func NoSandbox(b bool) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.NoSandbox = b
+	}
+}
+
+// DisableGPU disables the hardware acceleration of the browser.
+func DisableGPU(b bool) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.DisableGPU = b
+	}
+}
+
+// UserAgent sets the user agent of the browser.
+func UserAgent(ua string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.UserAgent = ua
+	}
+}
+
+// ProxyServer sets the proxy server of the browser.
+func ProxyServer(server string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.ProxyServer = server
+	}
+}
+
+// Flag sets an extra command line flag of the browser.
+func Flag(name string, value interface{}) LaunchOption {
+	return func(opts *LaunchOptions) {
+		if opts.Flags == nil {
+			opts.Flags = make(map[string]interface{})
+		}
+		opts.Flags[name] = value
+	}
+}
+
+// RemoveFlags removes the flags from the default ones, such as disable-web-security.
+func RemoveFlags(names ...string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.RemoveFlags = append(opts.RemoveFlags, names...)
+	}
+}
+
+// WindowSize sets the window size of the browser.
+func WindowSize(width, height int) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.WindowWidth = width
+		opts.WindowHeight = height
+	}
+}
+
+// Env appends the environment variables of the browser process, as KEY=value.
+func Env(vars ...string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.Env = append(opts.Env, vars...)
+	}
+}
+
+// UserDataDir sets the user data directory of the browser.
+func UserDataDir(dir string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.UserDataDir = dir
+	}
+}
+
+// Extensions loads the unpacked extensions in the directories.
+func Extensions(dirs ...string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.Extensions = append(opts.Extensions, dirs...)
+	}
+}
+
+// WSURLReadTimeout sets the timeout of reading the websocket URL from the browser output.
+func WSURLReadTimeout(d time.Duration) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.WSURLReadTimeout = d
+	}
+}
+
+// allocatorOptions returns the options of the exec allocator of chromedp.
+func (o LaunchOptions) allocatorOptions() []chromedp.ExecAllocatorOption {
+	// https://github.com/chromedp/chromedp/blob/b56cd66f9cebd6a1fa1283847bbf507409d48225/allocate.go#L53
+	var allocOpts = append(
+		chromedp.DefaultExecAllocatorOptions[:],
+		// chromedp.CombinedOutput(log.Writer()),
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Flag("proxy-server", o.ProxyServer),
+	)
+	for name, value := range defaultLaunchFlags {
+		allocOpts = append(allocOpts, chromedp.Flag(name, value))
+	}
+	if o.ExecPath != "" {
+		allocOpts = append(allocOpts, chromedp.ExecPath(o.ExecPath))
+	}
+	if o.WSURLReadTimeout > 0 {
+		allocOpts = append(allocOpts, chromedp.WSURLReadTimeout(o.WSURLReadTimeout))
+	}
+	if !o.Headless {
+		allocOpts = append(allocOpts, chromedp.Flag("headless", false))
+	}
+	if o.NoSandbox {
+		allocOpts = append(allocOpts, chromedp.NoSandbox)
+	}
+	allocOpts = append(allocOpts, chromedp.Flag("disable-gpu", o.DisableGPU))
+	if o.UserAgent != "" {
+		allocOpts = append(allocOpts, chromedp.UserAgent(o.UserAgent))
+	}
+	if o.WindowWidth > 0 && o.WindowHeight > 0 {
+		allocOpts = append(allocOpts, chromedp.WindowSize(o.WindowWidth, o.WindowHeight))
+	}
+	if len(o.Env) > 0 {
+		allocOpts = append(allocOpts, chromedp.Env(o.Env...))
+	}
+	if o.UserDataDir != "" {
+		allocOpts = append(allocOpts, chromedp.UserDataDir(o.UserDataDir))
+	}
+	if len(o.Extensions) > 0 {
+		dirs := strings.Join(o.Extensions, ",")
+		allocOpts = append(allocOpts,
+			chromedp.Flag("disable-extensions", false),
+			chromedp.Flag("load-extension", dirs),
+			chromedp.Flag("disable-extensions-except", dirs),
+		)
+	}
+	for name, value := range o.Flags {
+		allocOpts = append(allocOpts, chromedp.Flag(name, value))
+	}
+	// A false value omits the flag.
+	for _, name := range o.RemoveFlags {
+		allocOpts = append(allocOpts, chromedp.Flag(strings.TrimPrefix(name, "--"), false))
+	}
+	return allocOpts
+}
+
+type chromeScreenshoter[T As] struct {
+	opts LaunchOptions
+}
+
+// NewChromeScreenshoter creates a Screenshoter launching a browser for each
+// capture, configured by DefaultLaunchOptions and the launch options.
+func NewChromeScreenshoter[T As](options ...LaunchOption) Screenshoter[T] {
+	opts := DefaultLaunchOptions()
+	for _, o := range options {
+		o(&opts)
+	}
+	return &chromeScreenshoter[T]{opts: opts}
+}
+
+func (s *chromeScreenshoter[T]) Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
+	execPath := s.opts.ExecPath
+	if execPath == "" {
+		execPath = helper.FindChromeExecPath()
+	}
+	if _, err := exec.LookPath(execPath); err != nil {
+		return nil, err
+	}
+
+	opts := s.opts
+	capture, options := pinProxy(options)
+	if capture.Proxy == "" && opts.ProxyServer != "" {
+		// Answer the credentials of the proxy of the browser.
+		capture.Proxy = opts.ProxyServer
+		options = append(options[:len(options):len(options)], Proxy(opts.ProxyServer))
+	}
+	server, _, err := parseProxy(capture.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	opts.ProxyServer = server
+	if server != "" && len(capture.ProxyBypass) > 0 {
+		flags := map[string]interface{}{"proxy-bypass-list": strings.Join(capture.ProxyBypass, ";")}
+		for name, value := range opts.Flags {
+			flags[name] = value
+		}
+		opts.Flags = flags
+	}
+	if opts.Profile != "" {
+		unlock, err := lockProfile(ctx, opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		opts.UserDataDir = opts.Profile
+	}
+	if opts.UserDataDir == "" {
+		dir, err := os.MkdirTemp(os.TempDir(), "chromedp-runner-*")
+		if err == nil && dir != "" {
+			defer os.RemoveAll(dir)
+			opts.UserDataDir = dir
+		}
+	}
+	ctx, cancel := chromedp.NewExecAllocator(ctx, opts.allocatorOptions()...)
+	defer cancel()
+
+	return screenshotStart[T](ctx, input, nil, options...)
+}
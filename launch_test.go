@@ -0,0 +1,56 @@
+package screenshot
+
+import (
+	"testing"
+)
+
+func TestDefaultLaunchOptions(t *testing.T) {
+	t.Setenv("CHROMEDP_NO_HEADLESS", "true")
+	t.Setenv("CHROMEDP_NO_SANDBOX", "false")
+	t.Setenv("CHROMEDP_USER_AGENT", "foo")
+	t.Setenv("CHROMEDP_WSURLREADTIMEOUT", "30")
+
+	opts := DefaultLaunchOptions()
+	if opts.Headless {
+		t.Error("unexpected headless with CHROMEDP_NO_HEADLESS")
+	}
+	if opts.NoSandbox {
+		t.Error("unexpected no sandbox with CHROMEDP_NO_SANDBOX=false")
+	}
+	if opts.UserAgent != "foo" {
+		t.Errorf("unexpected user agent %q", opts.UserAgent)
+	}
+	if opts.WSURLReadTimeout.Seconds() != 30 {
+		t.Errorf("unexpected websocket url read timeout %s", opts.WSURLReadTimeout)
+	}
+}
+
+func TestNewChromeScreenshoter(t *testing.T) {
+	s := NewChromeScreenshoter[Byte](
+		Headless(false),
+		RemoveFlags("disable-web-security"),
+		Flag("lang", "de-DE"),
+		WindowSize(1280, 800),
+		Extensions("/tmp/foo", "/tmp/bar"),
+	).(*chromeScreenshoter[Byte])
+
+	opts := s.opts
+	if opts.Headless {
+		t.Error("unexpected headless")
+	}
+	if len(opts.RemoveFlags) != 1 || opts.RemoveFlags[0] != "disable-web-security" {
+		t.Errorf("unexpected removed flags %v", opts.RemoveFlags)
+	}
+	if opts.Flags["lang"] != "de-DE" {
+		t.Errorf("unexpected flags %v", opts.Flags)
+	}
+	if opts.WindowWidth != 1280 || opts.WindowHeight != 800 {
+		t.Errorf("unexpected window size %dx%d", opts.WindowWidth, opts.WindowHeight)
+	}
+	if len(opts.Extensions) != 2 {
+		t.Errorf("unexpected extensions %v", opts.Extensions)
+	}
+	if n := len(opts.allocatorOptions()); n == 0 {
+		t.Error("unexpected empty allocator options")
+	}
+}
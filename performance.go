@@ -0,0 +1,150 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/performance"
+	"github.com/chromedp/chromedp"
+	"github.com/wabarc/helper"
+)
+
+// Performance represents a lightweight performance audit of a capture.
+// All durations are in milliseconds relative to the navigation start.
+type Performance struct {
+	// Metrics reported by the DevTools Performance domain, e.g. Nodes,
+	// JSHeapUsedSize, LayoutCount and ScriptDuration.
+	Metrics map[string]float64 `json:"metrics"`
+
+	// Navigation timing of the main document.
+	Navigation NavigationTiming `json:"navigation"`
+
+	LCP float64 `json:"lcp"` // Largest Contentful Paint.
+	CLS float64 `json:"cls"` // Cumulative Layout Shift, the sum of shifts without recent input.
+	FCP float64 `json:"fcp"` // First Contentful Paint.
+	TBT float64 `json:"tbt"` // Total Blocking Time of long tasks after FCP.
+
+	Requests  int64 `json:"requests"`  // Number of requests sent.
+	Responses int64 `json:"responses"` // Number of responses received.
+	Failures  int64 `json:"failures"`  // Number of requests failed to load.
+
+	// Total bytes of resources
+	DataLength int64 `json:"dataLength"`
+}
+
+// NavigationTiming represents the PerformanceNavigationTiming entry of the main document.
+type NavigationTiming struct {
+	Type             string  `json:"type"`
+	DNS              float64 `json:"dns"`
+	Connect          float64 `json:"connect"`
+	TLS              float64 `json:"tls"`
+	TTFB             float64 `json:"ttfb"`
+	Response         float64 `json:"response"`
+	DOMInteractive   float64 `json:"domInteractive"`
+	DOMContentLoaded float64 `json:"domContentLoaded"`
+	Load             float64 `json:"load"`
+	Duration         float64 `json:"duration"`
+	TransferSize     int64   `json:"transferSize"`
+	EncodedBodySize  int64   `json:"encodedBodySize"`
+	DecodedBodySize  int64   `json:"decodedBodySize"`
+}
+
+// performanceObserverScript buffers paint, layout shift and long task entries
+// from the very beginning of each document.
+const performanceObserverScript = `(function(w) {
+  const s = w.__screenshotPerformance = { lcp: 0, cls: 0, fcp: 0, longtasks: [] };
+  const observe = (type, fn) => {
+    try {
+      new PerformanceObserver((list) => list.getEntries().forEach(fn)).observe({ type: type, buffered: true });
+    } catch (_) {}
+  };
+  observe('largest-contentful-paint', (e) => { s.lcp = e.renderTime || e.loadTime || e.startTime; });
+  observe('layout-shift', (e) => { if (!e.hadRecentInput) s.cls += e.value; });
+  observe('paint', (e) => { if (e.name === 'first-contentful-paint') s.fcp = e.startTime; });
+  observe('longtask', (e) => { s.longtasks.push([e.startTime, e.duration]); });
+})(window);`
+
+// performanceCollectScript returns the buffered entries and the navigation
+// timing in the shape of Performance.
+const performanceCollectScript = `(() => {
+  const s = window.__screenshotPerformance || { lcp: 0, cls: 0, fcp: 0, longtasks: [] };
+  let tbt = 0;
+  for (const [start, duration] of s.longtasks) {
+    if (start >= s.fcp && duration > 50) tbt += duration - 50;
+  }
+  const n = performance.getEntriesByType('navigation')[0];
+  const navigation = !n ? {} : {
+    type: n.type,
+    dns: n.domainLookupEnd - n.domainLookupStart,
+    connect: n.connectEnd - n.connectStart,
+    tls: n.secureConnectionStart > 0 ? n.connectEnd - n.secureConnectionStart : 0,
+    ttfb: n.responseStart - n.startTime,
+    response: n.responseEnd - n.responseStart,
+    domInteractive: n.domInteractive,
+    domContentLoaded: n.domContentLoadedEventEnd,
+    load: n.loadEventEnd,
+    duration: n.duration,
+    transferSize: n.transferSize,
+    encodedBodySize: n.encodedBodySize,
+    decodedBodySize: n.decodedBodySize,
+  };
+  return { lcp: s.lcp, cls: s.cls, fcp: s.fcp, tbt: tbt, navigation: navigation };
+})()`
+
+func observePerformance(options ScreenshotOptions) chromedp.Action {
+	if !options.Performance {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{
+		performance.Enable(),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(performanceObserverScript).Do(ctx)
+			return err
+		}),
+	}
+}
+
+func collectPerformance(res *Performance, options ScreenshotOptions) chromedp.Action {
+	if !options.Performance {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{
+		chromedp.Evaluate(performanceCollectScript, res),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			metrics, err := performance.GetMetrics().Do(ctx)
+			if err != nil {
+				return err
+			}
+			res.Metrics = make(map[string]float64, len(metrics))
+			for _, m := range metrics {
+				res.Metrics[m.Name] = m.Value
+			}
+			return nil
+		}),
+	}
+}
+
+// writePerformance writes the performance report as JSON to the file
+// specified by Files.Performance, if any.
+func writePerformance(res *Performance, options ScreenshotOptions) error {
+	if res == nil || options.Files.Performance == "" {
+		return nil
+	}
+
+	buf, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = helper.WriteFile(options.Files.Performance, buf, perm); err != nil {
+		return &ArtifactError{Path: options.Files.Performance, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrProfileLocked is returned if the profile is used by another browser.
+var ErrProfileLocked = errors.New("screenshot: profile is in use")
+
+const profileLockFile = ".screenshot.lock"
+
+// errFileLocked is returned by lockFile if the file is locked by another one.
+var errFileLocked = errors.New("file is locked")
+
+// Profile uses the directory as persistent user data directory of the browser,
+// so that the cookies and storage of a session, e.g. logged in by Login, are
+// kept across captures. Captures using the same profile run in turn.
+func Profile(dir string) LaunchOption {
+	return func(opts *LaunchOptions) {
+		opts.Profile = dir
+	}
+}
+
+// ProfileLocked reports whether the profile is used by a browser.
+func ProfileLocked(dir string) bool {
+	f, err := os.OpenFile(filepath.Join(dir, profileLockFile), os.O_RDWR, 0o600)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return errors.Is(err, errFileLocked)
+	}
+	unlockFile(f) // nolint:errcheck
+	return false
+}
+
+// lockProfile locks the profile by a lock on the lock file, waiting for the
+// other browser using it. The lock is held by the operating system, so that
+// it is released once the process is gone.
+func lockProfile(ctx context.Context, dir string) (unlock func(), err error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, profileLockFile), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err := lockFile(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errFileLocked) {
+			f.Close()
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("%w: %s: %v", ErrProfileLocked, dir, ctx.Err())
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	// The process id is informational only, for the one looking into the profile.
+	if err := f.Truncate(0); err == nil {
+		fmt.Fprintf(f, "%d", os.Getpid()) // nolint:errcheck
+	}
+	return func() {
+		unlockFile(f) // nolint:errcheck
+		f.Close()
+	}, nil
+}
+
+// Login opens the page in a browser with window using the profile of the
+// launch options, and waits until the window is closed or ctx is done, so that
+// the user can log in once for the later headless captures using the profile.
+// It returns the error of ctx if done before the window is closed.
+func Login(ctx context.Context, input *url.URL, options ...LaunchOption) error {
+	opts := DefaultLaunchOptions()
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.Profile == "" {
+		return fmt.Errorf("screenshot: login requires a profile")
+	}
+	opts.Headless = false
+
+	unlock, err := lockProfile(ctx, opts.Profile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	opts.UserDataDir = opts.Profile
+
+	parent := ctx
+	ctx, cancel := chromedp.NewExecAllocator(ctx, opts.allocatorOptions()...)
+	defer cancel()
+	ctx, cancel = chromedp.NewContext(ctx)
+	defer cancel()
+
+	closed := make(chan struct{})
+	chromedp.ListenTarget(ctx, func(v interface{}) {
+		if _, ok := v.(*inspector.EventDetached); ok {
+			select {
+			case <-closed:
+			default:
+				close(closed)
+			}
+		}
+	})
+	if err := chromedp.Run(ctx, chromedp.Navigate(input.String())); err != nil {
+		return err
+	}
+
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		// The browser exits once its window is closed, the login is
+		// unfinished if ctx is done before.
+		return parent.Err()
+	}
+}
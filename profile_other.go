@@ -0,0 +1,19 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import "os"
+
+// lockFile does not lock the file, file locks are not supported on this
+// platform, so that profiles are not guarded against concurrent browsers.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
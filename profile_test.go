@@ -0,0 +1,57 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockProfile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profile")
+
+	unlock, err := lockProfile(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ProfileLocked(dir) {
+		t.Fatal("unexpected profile not locked")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := lockProfile(ctx, dir); !errors.Is(err, ErrProfileLocked) {
+		t.Fatalf("unexpected lock of locked profile: %v", err)
+	}
+
+	unlock()
+	if ProfileLocked(dir) {
+		t.Fatal("unexpected profile locked after unlock")
+	}
+	unlock, err = lockProfile(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestLockProfileStale(t *testing.T) {
+	dir := t.TempDir()
+	// A lock file left by a process gone, which holds no lock.
+	if err := os.WriteFile(filepath.Join(dir, profileLockFile), []byte("2147483646"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ProfileLocked(dir) {
+		t.Fatal("unexpected profile locked by a process gone")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := lockProfile(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
@@ -0,0 +1,559 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrNoBrowser is returned by a remote screenshoter if none of its browsers is reachable.
+var ErrNoBrowser = errors.New("screenshot: no remote browser available")
+
+// RemoteScreenshoter is a Screenshoter backed by remote browsers, it should be
+// closed once no longer used.
+type RemoteScreenshoter[T As] interface {
+	Screenshoter[T]
+
+	// Close stops the health checks and closes the connections to the browsers.
+	Close() error
+}
+
+// RemoteOptions is the options used by NewChromeRemotePool.
+type RemoteOptions struct {
+	// Interval between health checks of the browsers, defaults to 10 seconds
+	// if not positive.
+	HealthCheckInterval time.Duration
+	// Timeout of resolving the websocket URL and checking a browser, defaults
+	// to 5 seconds if not positive.
+	HealthCheckTimeout time.Duration
+	// DisableHealthChecks stops checking the browsers periodically, they are
+	// still checked on creating the pool and after connection errors.
+	DisableHealthChecks bool
+
+	// Close the connection to a browser once no capture uses it, instead of
+	// keeping it for the following captures.
+	closeIdle bool
+}
+
+type RemoteOption func(*RemoteOptions)
+
+// HealthCheckInterval sets the interval between health checks of the browsers.
+func HealthCheckInterval(d time.Duration) RemoteOption {
+	return func(opts *RemoteOptions) {
+		opts.HealthCheckInterval = d
+	}
+}
+
+// HealthCheckTimeout sets the timeout of checking a browser.
+func HealthCheckTimeout(d time.Duration) RemoteOption {
+	return func(opts *RemoteOptions) {
+		opts.HealthCheckTimeout = d
+	}
+}
+
+// DisableHealthChecks disables the periodic health checks of the browsers.
+func DisableHealthChecks() RemoteOption {
+	return func(opts *RemoteOptions) {
+		opts.DisableHealthChecks = true
+	}
+}
+
+// endpoint is a remote browser, addressed by host:port of its DevTools HTTP
+// endpoint or by its websocket debugger URL.
+type endpoint struct {
+	addr string
+
+	// Timeout of connecting to the browser, in addition to the deadline of the capture.
+	dialTimeout time.Duration
+	// Whether to close the connection once no capture uses it.
+	closeIdle bool
+
+	mu      sync.Mutex
+	wsURL   string
+	healthy bool
+
+	inflight int64
+
+	// Connection to the browser shared by the captures.
+	connMu  sync.Mutex
+	conn    *connection
+	dialing *dialing // Connection in progress, if any.
+	gen     int      // Incremented once the connection is replaced.
+}
+
+// connection is a connection to a browser, which is closed once replaced and
+// its captures in flight finished.
+type connection struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	users  int  // Captures in flight, guarded by endpoint.connMu.
+	stale  bool // Replaced by a connection to a new websocket URL.
+}
+
+// dialing is a connection to a browser in progress, the other captures wait
+// for it instead of connecting again.
+type dialing struct {
+	done     chan struct{}
+	err      error
+	canceled bool // The capture connecting was cancelled, the others connect themselves.
+}
+
+func (e *endpoint) websocket() bool {
+	return strings.HasPrefix(e.addr, "wss://") || strings.HasPrefix(e.addr, "ws://")
+}
+
+// resolve reads the websocket debugger URL of the browser from /json/version,
+// which changes once the browser restarted. A websocket endpoint is checked
+// by dialing its host.
+func (e *endpoint) resolve(ctx context.Context) (err error) {
+	defer func() {
+		e.mu.Lock()
+		e.healthy = err == nil
+		e.mu.Unlock()
+	}()
+
+	if e.websocket() {
+		u, err := url.Parse(e.addr)
+		if err != nil {
+			return err
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), map[string]string{"ws": "80", "wss": "443"}[u.Scheme])
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return err
+		}
+		e.setURL(e.addr)
+		return conn.Close()
+	}
+
+	// Due to issue#505 (https://github.com/chromedp/chromedp/issues/505),
+	// chrome restricts the host must be IP or localhost, we should rewrite the url.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/json/version", e.addr), nil)
+	if err != nil {
+		return err
+	}
+	req.Host = "localhost"
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.WebSocketDebuggerURL == "" {
+		return fmt.Errorf("screenshot: %s: missing websocket debugger url", e.addr)
+	}
+	e.setURL(strings.Replace(result.WebSocketDebuggerURL, "localhost", e.addr, 1))
+	return nil
+}
+
+// setURL sets the websocket URL. The captures in flight finish on the
+// connection to the former URL, the new captures connect to the new one.
+func (e *endpoint) setURL(wsURL string) {
+	e.mu.Lock()
+	changed := e.wsURL != wsURL
+	e.wsURL = wsURL
+	e.mu.Unlock()
+	if changed {
+		e.retire()
+	}
+}
+
+// connect returns the connection to the browser for a capture, connecting
+// again if it has been lost. The capture releases it once finished.
+func (e *endpoint) connect(ctx context.Context) (*connection, error) {
+	for {
+		e.connMu.Lock()
+		if c := e.conn; c != nil && c.ctx.Err() == nil {
+			c.users++
+			e.connMu.Unlock()
+			return c, nil
+		}
+		if d := e.dialing; d != nil {
+			e.connMu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-d.done:
+			}
+			if d.err != nil && !d.canceled {
+				return nil, d.err
+			}
+			continue
+		}
+		d := &dialing{done: make(chan struct{})}
+		e.dialing = d
+		gen := e.gen
+		e.connMu.Unlock()
+
+		c, err := e.dial(ctx)
+
+		e.connMu.Lock()
+		e.dialing = nil
+		d.err, d.canceled = err, ctx.Err() != nil
+		close(d.done)
+		if err == nil {
+			c.users = 1
+			if e.gen == gen {
+				e.conn = c
+			} else {
+				// Replaced while connecting, closed once the capture finished.
+				c.stale = true
+			}
+		}
+		e.connMu.Unlock()
+		return c, err
+	}
+}
+
+// dial connects to the browser by a blank tab, which is kept until
+// disconnected. The connection outlives the capture, but connecting gives up
+// once the capture is done or after the dial timeout.
+func (e *endpoint) dial(ctx context.Context) (*connection, error) {
+	wsURL, _ := e.state()
+	if wsURL == "" {
+		return nil, fmt.Errorf("can't connect to headless browser")
+	}
+	var opts []chromedp.RemoteAllocatorOption
+	if e.websocket() {
+		// added https://github.com/chromedp/chromedp/pull/1184
+		opts = append(opts, chromedp.NoModifyURL)
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewRemoteAllocator(context.Background(), wsURL, opts...)
+	cctx, cancel := chromedp.NewContext(allocCtx)
+	c := &connection{
+		ctx: cctx,
+		cancel: func() {
+			cancel()
+			cancelAlloc()
+		},
+	}
+
+	if e.dialTimeout > 0 {
+		var cancelDial context.CancelFunc
+		ctx, cancelDial = context.WithTimeout(ctx, e.dialTimeout)
+		defer cancelDial()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- chromedp.Run(cctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			c.cancel()
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		c.cancel()
+		return nil, fmt.Errorf("connect to %s: %w", e.addr, ctx.Err())
+	}
+}
+
+// release releases the connection of a finished capture, and closes it if
+// no other capture uses it and it has been replaced or is not kept.
+func (e *endpoint) release(c *connection) {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	c.users--
+	if (c.stale || e.closeIdle) && c.users <= 0 {
+		c.cancel()
+		if e.conn == c {
+			e.conn = nil
+		}
+	}
+}
+
+// retire replaces the connection for new captures, it is closed once its
+// captures in flight finished.
+func (e *endpoint) retire() {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	if c := e.conn; c != nil {
+		c.stale = true
+		if c.users <= 0 {
+			c.cancel()
+		}
+	}
+	e.conn = nil
+	e.gen++
+}
+
+// disconnect closes the connection at once, cancelling the captures in flight.
+func (e *endpoint) disconnect() {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	if e.conn != nil {
+		e.conn.cancel()
+	}
+	e.conn = nil
+	e.gen++
+}
+
+func (e *endpoint) state() (wsURL string, healthy bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.wsURL, e.healthy
+}
+
+func (e *endpoint) markDown() {
+	e.mu.Lock()
+	e.healthy = false
+	e.mu.Unlock()
+}
+
+// screenshotRemote captures the page over the shared connection to the browser,
+// in a new browser context isolating the cookies and storage of the capture.
+// It reports whether the connection could not be made or was lost during the
+// capture, e.g. the browser crashed, which cancels the capture without the caller.
+func screenshotRemote[T As](ctx context.Context, e *endpoint, input *url.URL, options ...ScreenshotOption) (shot *Screenshots[T], lost bool, err error) {
+	atomic.AddInt64(&e.inflight, 1)
+	defer atomic.AddInt64(&e.inflight, -1)
+
+	conn, err := e.connect(ctx)
+	if err != nil {
+		return nil, ctx.Err() == nil, &StageError{Stage: StageSetup, Err: err}
+	}
+	defer e.release(conn)
+
+	// The capture is bound to the connection, with the deadline and the
+	// cancellation of the caller.
+	cctx, cancel := context.WithCancel(conn.ctx)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		cctx, cancelDeadline = context.WithDeadline(cctx, deadline)
+		defer cancelDeadline()
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-cctx.Done():
+		}
+	}()
+
+	capture, options := pinProxy(options)
+	proxy, err := proxyBrowserContext(capture)
+	if err != nil {
+		return nil, false, &StageError{Stage: StageSetup, Err: err}
+	}
+	shot, err = screenshotStart[T](cctx, input, []chromedp.ContextOption{chromedp.WithNewBrowserContext(proxy)}, options...)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		if !errors.Is(err, ctx.Err()) {
+			err = fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+	case conn.ctx.Err() != nil:
+		lost = true
+		err = lostConnection(err)
+	}
+	return shot, lost, err
+}
+
+type chromeRemoteScreenshoter[T As] struct {
+	endpoints []*endpoint
+	opts      RemoteOptions
+
+	next uint32
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewChromeRemoteScreenshoter creates a Screenshoter backed by Chrome DevTools Protocol.
+// The addr is the headless chrome websocket debugger endpoint, such as 127.0.0.1:9222.
+// Unlike NewChromeRemotePool, it does not check the browser periodically and
+// connects to it for each capture, so that closing it is not required.
+func NewChromeRemoteScreenshoter[T As](addr string) (RemoteScreenshoter[T], error) {
+	return NewChromeRemotePool[T]([]string{addr}, DisableHealthChecks(), func(opts *RemoteOptions) {
+		opts.closeIdle = true
+	})
+}
+
+// NewChromeRemotePool creates a Screenshoter backed by several remote browsers,
+// each address is such as 127.0.0.1:9222 or a websocket debugger endpoint.
+// Each capture runs on the healthy browser with the fewest captures in flight,
+// and fails over to the next browser on connection errors, including a
+// connection lost during the capture, e.g. a crashed browser. The websocket URLs
+// are resolved again by health checks and after connection errors, so that
+// a restarted browser is reachable again.
+func NewChromeRemotePool[T As](addrs []string, options ...RemoteOption) (RemoteScreenshoter[T], error) {
+	var opts RemoteOptions
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.HealthCheckInterval <= 0 {
+		opts.HealthCheckInterval = 10 * time.Second
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = 5 * time.Second
+	}
+
+	s := &chromeRemoteScreenshoter[T]{opts: opts, done: make(chan struct{})}
+	for _, addr := range addrs {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			s.endpoints = append(s.endpoints, &endpoint{addr: addr, dialTimeout: opts.HealthCheckTimeout, closeIdle: opts.closeIdle})
+		}
+	}
+	if len(s.endpoints) == 0 {
+		return nil, ErrNoBrowser
+	}
+
+	// At least one of the browsers must be reachable.
+	var errs []error
+	for _, err := range s.check(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(s.endpoints) {
+		return nil, errs[0]
+	}
+
+	if !opts.DisableHealthChecks {
+		go s.healthCheck()
+	}
+	return s, nil
+}
+
+// check resolves all the browsers concurrently.
+func (s *chromeRemoteScreenshoter[T]) check(ctx context.Context) []error {
+	errs := make([]error, len(s.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range s.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, s.opts.HealthCheckTimeout)
+			defer cancel()
+			errs[i] = e.resolve(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+	return errs
+}
+
+func (s *chromeRemoteScreenshoter[T]) healthCheck() {
+	ticker := time.NewTicker(s.opts.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.check(context.Background())
+		}
+	}
+}
+
+// pick returns the healthy browser with the fewest captures in flight which
+// has not been tried, or an unhealthy one if none, which may have recovered.
+func (s *chromeRemoteScreenshoter[T]) pick(tried map[*endpoint]bool) *endpoint {
+	// Start from a rotating offset to spread the ties.
+	offset := int(atomic.AddUint32(&s.next, 1))
+	var best, fallback *endpoint
+	for i := range s.endpoints {
+		e := s.endpoints[(offset+i)%len(s.endpoints)]
+		if tried[e] {
+			continue
+		}
+		if _, healthy := e.state(); !healthy {
+			if fallback == nil {
+				fallback = e
+			}
+			continue
+		}
+		if best == nil || atomic.LoadInt64(&e.inflight) < atomic.LoadInt64(&best.inflight) {
+			best = e
+		}
+	}
+	if best == nil {
+		return fallback
+	}
+	return best
+}
+
+func (s *chromeRemoteScreenshoter[T]) Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
+	tried := make(map[*endpoint]bool)
+	err := ErrNoBrowser
+	for {
+		e := s.pick(tried)
+		if e == nil {
+			return nil, err
+		}
+		tried[e] = true
+
+		var shot *Screenshots[T]
+		var lost bool
+		shot, lost, err = screenshotRemote[T](ctx, e, input, options...)
+		if !connectionFailed(ctx, shot, lost, err) {
+			return shot, err
+		}
+
+		// The browser may have been restarted with a new websocket URL.
+		e.markDown()
+		e.disconnect()
+		rctx, cancel := context.WithTimeout(ctx, s.opts.HealthCheckTimeout)
+		rerr := e.resolve(rctx)
+		cancel()
+		if rerr != nil {
+			continue
+		}
+		shot, lost, err = screenshotRemote[T](ctx, e, input, options...)
+		if !connectionFailed(ctx, shot, lost, err) {
+			return shot, err
+		}
+		e.markDown()
+		e.disconnect()
+	}
+}
+
+// connectionFailed reports whether the capture failed due to the connection
+// to the browser, so that it fails over to the next browser: the connection
+// was lost during the capture, or could not be made.
+func connectionFailed[T As](ctx context.Context, shot *Screenshots[T], lost bool, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	return lost || (shot == nil && isConnectionError(err))
+}
+
+func (s *chromeRemoteScreenshoter[T]) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		for _, e := range s.endpoints {
+			e.disconnect()
+		}
+	})
+	return nil
+}
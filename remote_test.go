@@ -0,0 +1,427 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wabarc/helper"
+)
+
+func newDevToolsServer(id string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/version" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"webSocketDebuggerUrl": "ws://localhost/devtools/browser/%s"}`, id)
+	}))
+}
+
+func TestNewChromeRemotePool(t *testing.T) {
+	ts := newDevToolsServer("foo")
+	defer ts.Close()
+
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	pool, err := NewChromeRemotePool[Byte]([]string{"127.0.0.1:1", addr}, DisableHealthChecks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	s := pool.(*chromeRemoteScreenshoter[Byte])
+	if _, healthy := s.endpoints[0].state(); healthy {
+		t.Error("unexpected unreachable browser healthy")
+	}
+	wsURL, healthy := s.endpoints[1].state()
+	if !healthy {
+		t.Error("unexpected reachable browser unhealthy")
+	}
+	if want := "ws://" + addr + "/devtools/browser/foo"; wsURL != want {
+		t.Errorf("unexpected websocket url, got %s instead of %s", wsURL, want)
+	}
+}
+
+func TestNewChromeRemoteScreenshoter(t *testing.T) {
+	ts := newDevToolsServer("foo")
+	defer ts.Close()
+
+	r, err := NewChromeRemoteScreenshoter[Byte](strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := r.(*chromeRemoteScreenshoter[Byte])
+	if !s.opts.DisableHealthChecks {
+		t.Error("unexpected health checks of a single browser")
+	}
+
+	// The connection is closed once the capture finished.
+	e := s.endpoints[0]
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &connection{ctx: ctx, cancel: cancel, users: 1}
+	e.conn = c
+	e.release(c)
+	if ctx.Err() == nil || e.conn != nil {
+		t.Error("unexpected connection kept after the capture")
+	}
+}
+
+func TestNewChromeRemotePoolUnreachable(t *testing.T) {
+	if _, err := NewChromeRemotePool[Byte](nil); !errors.Is(err, ErrNoBrowser) {
+		t.Errorf("unexpected error without browser: %v", err)
+	}
+	if _, err := NewChromeRemotePool[Byte]([]string{"127.0.0.1:1"}, HealthCheckTimeout(time.Second)); err == nil {
+		t.Error("unexpected pool of unreachable browsers without error")
+	}
+}
+
+func TestRemotePoolPick(t *testing.T) {
+	busy := &endpoint{addr: "busy", healthy: true, inflight: 2}
+	idle := &endpoint{addr: "idle", healthy: true, inflight: 1}
+	down := &endpoint{addr: "down"}
+	s := &chromeRemoteScreenshoter[Byte]{endpoints: []*endpoint{busy, down, idle}}
+
+	for i := 0; i < len(s.endpoints); i++ {
+		if e := s.pick(map[*endpoint]bool{}); e != idle {
+			t.Fatalf("unexpected pick %s instead of the least busy browser", e.addr)
+		}
+	}
+	if e := s.pick(map[*endpoint]bool{idle: true}); e != busy {
+		t.Errorf("unexpected pick %s instead of the next healthy browser", e.addr)
+	}
+	if e := s.pick(map[*endpoint]bool{idle: true, busy: true}); e != down {
+		t.Errorf("unexpected pick %s instead of the unhealthy browser", e.addr)
+	}
+	if e := s.pick(map[*endpoint]bool{idle: true, busy: true, down: true}); e != nil {
+		t.Errorf("unexpected pick %s of tried browsers", e.addr)
+	}
+}
+
+func TestRemotePoolReresolve(t *testing.T) {
+	ts := newDevToolsServer("foo")
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	pool, err := NewChromeRemotePool[Byte]([]string{addr}, HealthCheckInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	ts.Close()
+
+	e := pool.(*chromeRemoteScreenshoter[Byte]).endpoints[0]
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, healthy := e.state(); !healthy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("unexpected stopped browser healthy")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRemotePoolDefaults(t *testing.T) {
+	ts := newDevToolsServer("foo")
+	defer ts.Close()
+
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	pool, err := NewChromeRemotePool[Byte]([]string{addr}, HealthCheckInterval(0), HealthCheckTimeout(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	opts := pool.(*chromeRemoteScreenshoter[Byte]).opts
+	if opts.HealthCheckInterval != 10*time.Second || opts.HealthCheckTimeout != 5*time.Second || opts.DisableHealthChecks {
+		t.Errorf("unexpected remote options got %+v", opts)
+	}
+}
+
+func TestEndpointRetire(t *testing.T) {
+	e := &endpoint{addr: "foo", wsURL: "ws://foo/devtools/browser/1"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn := &connection{ctx: ctx, cancel: cancel, users: 1}
+	e.conn = conn
+
+	// The capture in flight keeps the connection to the former URL.
+	e.setURL("ws://foo/devtools/browser/2")
+	if e.conn != nil {
+		t.Error("unexpected connection to the former url kept for new captures")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("unexpected connection closed with a capture in flight")
+	}
+	e.release(conn)
+	if ctx.Err() == nil {
+		t.Error("unexpected replaced connection open after its captures finished")
+	}
+
+	// An idle connection is closed at once.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	e.conn = &connection{ctx: ctx, cancel: cancel}
+	e.setURL("ws://foo/devtools/browser/3")
+	if ctx.Err() == nil {
+		t.Error("unexpected idle connection open after replaced")
+	}
+}
+
+func TestEndpointConnectHang(t *testing.T) {
+	// The browser accepts connections but never answers.
+	release := make(chan struct{})
+	var mu sync.Mutex
+	dials := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		dials++
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+	wsURL := strings.Replace(ts.URL, "http://", "ws://", 1) + "/devtools/browser/1"
+
+	e := &endpoint{addr: wsURL, wsURL: wsURL, dialTimeout: 200 * time.Millisecond}
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	start := time.Now()
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = e.connect(context.Background())
+		}(i)
+	}
+	// Retiring the connection does not wait for the connection in progress.
+	time.Sleep(50 * time.Millisecond)
+	e.retire()
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Errorf("unexpected retire blocked by connecting for %s", d)
+	}
+	wg.Wait()
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("unexpected connecting for %s beyond the dial timeout", d)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error got %v instead of deadline exceeded", err)
+		}
+	}
+	mu.Lock()
+	if dials != 1 {
+		t.Errorf("unexpected number of dials got %d instead of 1", dials)
+	}
+	mu.Unlock()
+
+	// The deadline of the capture bounds connecting too.
+	e.dialTimeout = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := e.connect(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error got %v instead of deadline exceeded", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("unexpected connecting for %s beyond the deadline", d)
+	}
+}
+
+func TestRemotePoolConnection(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// The page reports the cookies of former captures, and sets one.
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body>
+<script>
+document.title = 'cookie:' + document.cookie;
+document.cookie = 'seen=1; max-age=3600';
+</script>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	cmd := exec.Command(binPath, "--headless", "--disable-gpu", "--no-sandbox", "--remote-debugging-address=127.0.0.1", "--remote-debugging-port=9223")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start Chromium headless failed: %v", err)
+	}
+	go func() {
+		cmd.Wait() // nolint:errcheck
+	}()
+	time.Sleep(7 * time.Second)
+	defer func() {
+		if err := cmd.Process.Kill(); err != nil {
+			t.Errorf("Failed to kill process: %v", err)
+		}
+	}()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewChromeRemotePool[Byte]([]string{"127.0.0.1:9223"}, DisableHealthChecks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := pool.(*chromeRemoteScreenshoter[Byte]).endpoints[0]
+
+	var conn *connection
+	for i := 0; i < 2; i++ {
+		shot, err := pool.Screenshot(ctx, input, ExportCookies(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Each capture runs in a browser context of its own.
+		if shot.Title != "cookie:" {
+			t.Errorf("unexpected cookies of a former capture, title: %s", shot.Title)
+		}
+		if len(shot.Cookies) != 1 || shot.Cookies[0].Name != "seen" {
+			t.Errorf("unexpected cookies exported from the browser context got %+v", shot.Cookies)
+		}
+		e.connMu.Lock()
+		if conn == nil {
+			conn = e.conn
+		} else if e.conn != conn {
+			t.Error("unexpected new connection instead of the shared one")
+		}
+		if e.conn == nil || e.conn.users != 0 {
+			t.Errorf("unexpected connection state after capture got %+v", e.conn)
+		}
+		e.connMu.Unlock()
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if conn == nil || conn.ctx.Err() == nil {
+		t.Error("unexpected connection open after closed")
+	}
+}
+
+func TestConnectionFailed(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	shot := &Screenshots[Byte]{}
+	partial := &CaptureError{Errors: []error{&StageError{Stage: StageScreenshot, Err: context.Canceled}}}
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		shot *Screenshots[Byte]
+		lost bool
+		err  error
+		want bool
+	}{
+		{"succeeded", context.Background(), shot, false, nil, false},
+		{"lost connection", context.Background(), shot, true, lostConnection(partial), true},
+		{"lost connection before navigating", context.Background(), nil, true, lostConnection(context.Canceled), true},
+		{"could not dial", context.Background(), nil, false, errors.New("could not dial"), true},
+		{"cancelled by the caller", canceled, shot, true, partial, false},
+		{"stages failed", context.Background(), shot, false, partial, false},
+		{"not found", context.Background(), nil, false, &HTTPStatusError{StatusCode: http.StatusNotFound}, false},
+	} {
+		if got := connectionFailed(tt.ctx, tt.shot, tt.lost, tt.err); got != tt.want {
+			t.Errorf("unexpected connection failed of %s got %t instead of %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRemotePoolFailover(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Each browser tells itself by the user agent.
+	browsers := make(map[string]*exec.Cmd)
+	for _, port := range []string{"9224", "9225"} {
+		cmd := exec.Command(binPath, "--headless", "--disable-gpu", "--no-sandbox", "--remote-debugging-address=127.0.0.1",
+			"--remote-debugging-port="+port, "--user-agent=browser-"+port)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("Start Chromium headless failed: %v", err)
+		}
+		go func() {
+			cmd.Wait() // nolint:errcheck
+		}()
+		defer cmd.Process.Kill() // nolint:errcheck
+		browsers["browser-"+port] = cmd
+	}
+	time.Sleep(7 * time.Second)
+
+	// The first browser loading the page crashes during the capture.
+	var mu sync.Mutex
+	var crashed string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		ua := r.UserAgent()
+		mu.Lock()
+		if crashed == "" {
+			crashed = ua
+			browsers[ua].Process.Kill() // nolint:errcheck
+		}
+		crash := ua == crashed
+		mu.Unlock()
+		if crash {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Example Domain</title></head><body></body></html>`)
+	}))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := NewChromeRemotePool[Byte]([]string{"127.0.0.1:9224", "127.0.0.1:9225"}, DisableHealthChecks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	shot, err := pool.Screenshot(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shot.Title != "Example Domain" || shot.Image == nil {
+		t.Errorf("unexpected capture of the other browser, title: %s", shot.Title)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if crashed == "" {
+		t.Fatal("unexpected no browser crashed")
+	}
+	for _, e := range pool.(*chromeRemoteScreenshoter[Byte]).endpoints {
+		// The user agent of the browser is browser- followed by its port.
+		down := "browser-" + strings.TrimPrefix(e.addr, "127.0.0.1:")
+		if _, healthy := e.state(); healthy == (down == crashed) {
+			t.Errorf("unexpected health of browser %s got %t", e.addr, healthy)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ScreenshoterFunc is an adapter to use a function as Screenshoter,
+// e.g. ScreenshoterFunc[Byte](Screenshot[Byte]).
+type ScreenshoterFunc[T As] func(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error)
+
+// Screenshot calls f(ctx, input, options...).
+func (f ScreenshoterFunc[T]) Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
+	return f(ctx, input, options...)
+}
+
+// RetryPolicy controls how Retry repeats a failed capture. The zero value
+// makes up to 3 attempts with exponential backoff from 1 second, randomized
+// by 20% in both directions.
+type RetryPolicy struct {
+	MaxAttempts    int           // Maximum number of attempts, including the first one.
+	InitialBackoff time.Duration // Backoff before the second attempt.
+	MaxBackoff     time.Duration // Upper bound of the backoff.
+	Multiplier     float64       // Growth factor of the backoff per attempt.
+	// Fraction of the backoff randomized in both directions, up to 1.
+	// Defaults to 0.2 if zero, a negative jitter disables it.
+	Jitter float64
+
+	// Retryable reports whether a failed capture should be retried, defaults to IsRetryable.
+	Retryable func(error) bool
+}
+
+// Attempt records an attempt of a capture.
+type Attempt struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// RetryError is returned by Retry if all attempts fail, it wraps the error of the last attempt.
+type RetryError struct {
+	Attempts []Attempt
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("screenshot: gave up after %d attempts: %v", len(e.Attempts), e.Unwrap())
+}
+
+func (e *RetryError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+type retryScreenshoter[T As] struct {
+	Screenshoter[T]
+
+	policy RetryPolicy
+}
+
+// Retry returns a Screenshoter that retries transient failures of s according
+// to the policy. The attempts are recorded in Screenshots.Attempts.
+func Retry[T As](s Screenshoter[T], policy RetryPolicy) Screenshoter[T] {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = time.Second
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 30 * time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	switch {
+	case policy.Jitter == 0:
+		policy.Jitter = 0.2
+	case policy.Jitter < 0:
+		policy.Jitter = 0
+	case policy.Jitter > 1:
+		policy.Jitter = 1
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+
+	return &retryScreenshoter[T]{Screenshoter: s, policy: policy}
+}
+
+func (s *retryScreenshoter[T]) Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
+	var attempts []Attempt
+	for i := 0; ; i++ {
+		start := time.Now()
+		shot, err := s.Screenshoter.Screenshot(ctx, input, options...)
+		attempts = append(attempts, Attempt{Start: start, Duration: time.Since(start), Err: err})
+		if shot != nil {
+			shot.Attempts = attempts
+		}
+		// The capture is never retried once the caller cancelled it, a
+		// cancelled capture is a lost connection to the browser otherwise.
+		if err == nil || i+1 >= s.policy.MaxAttempts || ctx.Err() != nil || !s.policy.Retryable(err) {
+			if err != nil && len(attempts) > 1 {
+				err = &RetryError{Attempts: attempts}
+			}
+			return shot, err
+		}
+
+		timer := time.NewTimer(s.policy.backoff(i))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return shot, &RetryError{Attempts: attempts}
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay after the n-th attempt, counting from zero.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(n))
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1) // nolint:gosec
+	}
+	return time.Duration(d)
+}
+
+// Network errors reported by Chrome that are likely to succeed on retry.
+var retryableNetErrors = []string{
+	"net::ERR_CONNECTION_RESET",
+	"net::ERR_CONNECTION_CLOSED",
+	"net::ERR_CONNECTION_REFUSED",
+	"net::ERR_CONNECTION_TIMED_OUT",
+	"net::ERR_CONNECTION_ABORTED",
+	"net::ERR_TIMED_OUT",
+	"net::ERR_EMPTY_RESPONSE",
+	"net::ERR_NETWORK_CHANGED",
+	"net::ERR_INTERNET_DISCONNECTED",
+	"net::ERR_NAME_RESOLUTION_FAILED",
+	"net::ERR_PROXY_CONNECTION_FAILED",
+	"net::ERR_TUNNEL_CONNECTION_FAILED",
+	"net::ERR_HTTP2_PROTOCOL_ERROR",
+}
+
+// IsRetryable reports whether err is a transient failure: a browser crash,
+// a lost connection to the browser, a connection-level network error, a
+// timeout before capturing, or a 429, 502, 503 or 504 response of the main document.
+// A context.Canceled is taken as a lost connection, Retry checks the context
+// of the caller before, so that cancelled captures are never retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrBrowserCrashed) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	// Partial results are only retried if the browser crashed or the
+	// connection was lost, a timeout of a later stage such as scrolling is
+	// not transient.
+	var captureErr *CaptureError
+	if errors.As(err, &captureErr) {
+		return isConnectionError(err)
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var navErr *NavigationError
+	if errors.As(err, &navErr) {
+		for _, code := range retryableNetErrors {
+			if navErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err is caused by a dropped or refused
+// connection to the browser.
+func isConnectionError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		isConnErrno(err) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	// The websocket errors of chromedp are not typed.
+	msg := err.Error()
+	return strings.Contains(msg, "websocket") || strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "connection refused") || strings.Contains(msg, "could not dial")
+}
+
+// lostConnection marks the error of a capture whose connection to the
+// browser was lost as ErrBrowserCrashed, keeping the failed stages.
+func lostConnection(err error) error {
+	if err == nil || errors.Is(err, ErrBrowserCrashed) {
+		return err
+	}
+	var captureErr *CaptureError
+	if errors.As(err, &captureErr) {
+		errs := make([]error, 0, len(captureErr.Errors))
+		for _, err := range captureErr.Errors {
+			errs = append(errs, lostConnection(err))
+		}
+		return &CaptureError{Errors: errs}
+	}
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return &StageError{Stage: stageErr.Stage, Err: fmt.Errorf("%w: %v", ErrBrowserCrashed, stageErr.Err)}
+	}
+	return fmt.Errorf("%w: %v", ErrBrowserCrashed, err)
+}
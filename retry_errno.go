@@ -0,0 +1,17 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+//go:build !plan9
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isConnErrno reports whether err is a reset or refused connection.
+func isConnErrno(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,11 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+// isConnErrno reports false, the errors of plan9 are strings which are
+// matched by isConnectionError.
+func isConnErrno(err error) bool {
+	return false
+}
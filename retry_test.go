@@ -0,0 +1,162 @@
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, true},
+		{"lost connection", lostConnection(&CaptureError{Errors: []error{&StageError{Stage: StageScroll, Err: context.Canceled}}}), true},
+		{"crashed", &StageError{Stage: StageScreenshot, Err: fmt.Errorf("%w: %v", ErrBrowserCrashed, context.Canceled)}, true},
+		{"connection reset", &StageError{Stage: StageNavigate, Err: &NavigationError{Code: "net::ERR_CONNECTION_RESET"}}, true},
+		{"name not resolved", &StageError{Stage: StageNavigate, Err: &NavigationError{Code: "net::ERR_NAME_NOT_RESOLVED"}}, false},
+		{"service unavailable", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"not found", &HTTPStatusError{StatusCode: http.StatusNotFound}, false},
+		{"navigate timeout", &StageError{Stage: StageNavigate, Err: context.DeadlineExceeded}, true},
+		{"scroll timeout", &CaptureError{Errors: []error{&StageError{Stage: StageScroll, Err: chromedp.ErrPollingTimeout}}}, false},
+		{"websocket", errors.New("websocket: close 1006 (abnormal closure)"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsRetryable(test.err); got != test.want {
+				t.Errorf("unexpected retryable of %v got %t instead of %t", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	input, _ := url.Parse("https://example.com")
+	calls := 0
+	s := ScreenshoterFunc[Byte](func(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[Byte], error) {
+		calls++
+		if calls < 3 {
+			return nil, &StageError{Stage: StageNavigate, Err: &NavigationError{Code: "net::ERR_CONNECTION_RESET"}}
+		}
+		return &Screenshots[Byte]{URL: input.String()}, nil
+	})
+
+	shot, err := Retry[Byte](s, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}).Screenshot(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("unexpected number of calls got %d instead of %d", calls, 3)
+	}
+	if n := len(shot.Attempts); n != 3 {
+		t.Errorf("unexpected number of attempts got %d instead of %d", n, 3)
+	}
+
+	calls = 0
+	_, err = Retry[Byte](s, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}).Screenshot(context.Background(), input)
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Fatalf("unexpected error got %v instead of RetryError with 2 attempts", err)
+	}
+	var navErr *NavigationError
+	if !errors.As(err, &navErr) {
+		t.Errorf("unexpected error does not wrap NavigationError")
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	for n, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if got := p.backoff(n); got != want {
+			t.Errorf("unexpected backoff of attempt %d got %s instead of %s", n, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(0); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("unexpected backoff with jitter got %s", got)
+		}
+	}
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	s := ScreenshoterFunc[Byte](Screenshot[Byte])
+	for _, tt := range []struct {
+		jitter, want float64
+	}{
+		{0, 0.2},
+		{-1, 0},
+		{0.5, 0.5},
+		{3, 1},
+	} {
+		r := Retry[Byte](s, RetryPolicy{Jitter: tt.jitter}).(*retryScreenshoter[Byte])
+		if r.policy.Jitter != tt.want {
+			t.Errorf("unexpected jitter of %g got %g instead of %g", tt.jitter, r.policy.Jitter, tt.want)
+		}
+	}
+}
+
+func TestRetryLostConnection(t *testing.T) {
+	input, _ := url.Parse("https://example.com")
+	calls := 0
+	s := ScreenshoterFunc[Byte](func(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[Byte], error) {
+		calls++
+		if calls < 2 {
+			return &Screenshots[Byte]{URL: input.String()}, &CaptureError{Errors: []error{&StageError{Stage: StageScreenshot, Err: context.Canceled}}}
+		}
+		return &Screenshots[Byte]{URL: input.String()}, nil
+	})
+
+	if _, err := Retry[Byte](s, RetryPolicy{InitialBackoff: time.Millisecond}).Screenshot(context.Background(), input); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("unexpected number of calls got %d instead of %d", calls, 2)
+	}
+
+	// Cancelled by the caller.
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Retry[Byte](s, RetryPolicy{InitialBackoff: time.Millisecond}).Screenshot(ctx, input); err == nil {
+		t.Fatal("unexpected capture cancelled by the caller without error")
+	}
+	if calls != 1 {
+		t.Errorf("unexpected number of calls got %d instead of %d", calls, 1)
+	}
+}
+
+func TestLostConnection(t *testing.T) {
+	err := lostConnection(&CaptureError{Errors: []error{
+		&StageError{Stage: StageScreenshot, Err: context.Canceled},
+		&StageError{Stage: StageHTML, Err: context.Canceled},
+	}})
+	var captureErr *CaptureError
+	if !errors.As(err, &captureErr) {
+		t.Fatalf("unexpected error got %v instead of CaptureError", err)
+	}
+	if stages := captureErr.Stages(); len(stages) != 2 || stages[0] != StageScreenshot || stages[1] != StageHTML {
+		t.Errorf("unexpected stages got %v", stages)
+	}
+	if !errors.Is(err, ErrBrowserCrashed) {
+		t.Errorf("unexpected error does not wrap ErrBrowserCrashed: %v", err)
+	}
+
+	if err := lostConnection(errors.New("read: EOF")); !errors.Is(err, ErrBrowserCrashed) {
+		t.Errorf("unexpected error does not wrap ErrBrowserCrashed: %v", err)
+	}
+	if err := lostConnection(nil); err != nil {
+		t.Errorf("unexpected error got %v instead of nil", err)
+	}
+}
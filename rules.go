@@ -0,0 +1,427 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/publicsuffix"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule represents site-specific fixes applied to the pages of a domain.
+type Rule struct {
+	BeforeNavigate []string `yaml:"before-navigate,omitempty"` // Scripts evaluated on new documents before any script of the page.
+	AfterLoad      []string `yaml:"after-load,omitempty"`      // Scripts evaluated once the page is loaded.
+	BeforeCapture  []string `yaml:"before-capture,omitempty"`  // Scripts evaluated after scrolling, before capturing.
+	CSS            []string `yaml:"css,omitempty"`             // Style sheets injected once the page is loaded.
+
+	Hide   []string `yaml:"hide,omitempty"`   // Selectors of elements hidden before capturing.
+	Remove []string `yaml:"remove,omitempty"` // Selectors of elements removed before capturing.
+
+	// WaitFor is a selector waited for before hiding and removing elements,
+	// for at most WaitTimeout which defaults to 5 seconds.
+	WaitFor     string        `yaml:"wait-for,omitempty"`
+	WaitTimeout time.Duration `yaml:"wait-timeout,omitempty"`
+
+	// Extra HTTP headers sent with every request of the page.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Viewport emulated for the page, overriding the one of the configuration.
+	Viewport *Viewport `yaml:"viewport,omitempty"`
+}
+
+// Viewport represents the emulated screen of a page.
+type Viewport struct {
+	Width       int64   `yaml:"width"`
+	Height      int64   `yaml:"height"`
+	Mobile      bool    `yaml:"mobile,omitempty"`
+	ScaleFactor float64 `yaml:"scale-factor,omitempty"`
+}
+
+// Rules maps a host or an eTLD+1 domain, such as example.com, to its rule.
+type Rules map[string]Rule
+
+// Lookup returns the rules matching the host exactly, and the rules of
+// its eTLD+1 domain.
+func (r Rules) Lookup(host string) (rules []Rule) {
+	if len(r) == 0 {
+		return nil
+	}
+	if rule, ok := r[host]; ok {
+		rules = append(rules, rule)
+	}
+	if dom, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil && dom != host {
+		if rule, ok := r[dom]; ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Merge appends the rules of other to r.
+func (r Rules) Merge(other Rules) {
+	for domain, rule := range other {
+		cur := r[domain]
+		cur.BeforeNavigate = append(cur.BeforeNavigate, rule.BeforeNavigate...)
+		cur.AfterLoad = append(cur.AfterLoad, rule.AfterLoad...)
+		cur.BeforeCapture = append(cur.BeforeCapture, rule.BeforeCapture...)
+		cur.CSS = append(cur.CSS, rule.CSS...)
+		cur.Hide = append(cur.Hide, rule.Hide...)
+		cur.Remove = append(cur.Remove, rule.Remove...)
+		if rule.WaitFor != "" {
+			cur.WaitFor = rule.WaitFor
+			cur.WaitTimeout = rule.WaitTimeout
+		}
+		if len(rule.Headers) > 0 {
+			headers := make(map[string]string, len(cur.Headers)+len(rule.Headers))
+			for name, value := range cur.Headers {
+				headers[name] = value
+			}
+			for name, value := range rule.Headers {
+				headers[name] = value
+			}
+			cur.Headers = headers
+		}
+		if rule.Viewport != nil {
+			cur.Viewport = rule.Viewport
+		}
+		r[domain] = cur
+	}
+}
+
+// ImportRules imports rules by given byte with yaml configuration.
+// Format:
+// rules:
+//
+//	example.com:
+//	  after-load:
+//	    - 'document.querySelector(".popup")?.remove()'
+//	  css:
+//	    - '.banner { display: none !important; }'
+//	  hide:
+//	    - 'header.sticky'
+//	  remove:
+//	    - '#chat-widget'
+//	    - '.modal-overlay'
+//	  wait-for: '.modal-overlay'
+//	  wait-timeout: 3s
+//	  headers:
+//	    Accept-Language: 'de-DE'
+//	  viewport:
+//	    width: 375
+//	    height: 812
+//	    mobile: true
+func ImportRules(r []byte) (Rules, error) {
+	type configs struct {
+		Rules Rules `yaml:"rules"`
+	}
+	var cfg configs
+	if err := yaml.Unmarshal(r, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = Rules{}
+	}
+	return cfg.Rules, nil
+}
+
+// ImportRulesDir imports rules from a directory. Files with a .yaml or .yml
+// extension are imported by ImportRules, files named by a domain with a .js
+// extension are after-load scripts, and with a .css extension are style sheets
+// of the domain, such as example.com.js and example.com.css.
+func ImportRulesDir(dir string) (Rules, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := Rules{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		switch ext {
+		case ".yaml", ".yml", ".js", ".css":
+		default:
+			continue
+		}
+		buf, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		domain := strings.TrimSuffix(name, ext)
+		switch ext {
+		case ".yaml", ".yml":
+			r, err := ImportRules(buf)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			rules.Merge(r)
+		case ".js":
+			rules.Merge(Rules{domain: {AfterLoad: []string{string(buf)}}})
+		case ".css":
+			rules.Merge(Rules{domain: {CSS: []string{string(buf)}}})
+		}
+	}
+	return rules, nil
+}
+
+// WithRules applies the rules matching the host of the page.
+func WithRules(rules Rules) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Rules = rules
+	}
+}
+
+// BeforeNavigate evaluates the scripts on every new document of the page
+// before any script of the page.
+func BeforeNavigate(scripts ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.BeforeNavigate = append(opts.BeforeNavigate, scripts...)
+	}
+}
+
+// AfterLoad evaluates the scripts once the page is loaded.
+func AfterLoad(scripts ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.AfterLoad = append(opts.AfterLoad, scripts...)
+	}
+}
+
+// BeforeCapture evaluates the scripts after scrolling, before capturing.
+func BeforeCapture(scripts ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.BeforeCapture = append(opts.BeforeCapture, scripts...)
+	}
+}
+
+// InjectCSS injects the style sheets once the page is loaded.
+func InjectCSS(css ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.CSS = append(opts.CSS, css...)
+	}
+}
+
+// HideSelectors hides the elements matching the selectors before capturing.
+func HideSelectors(selectors ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.HideSelectors = append(opts.HideSelectors, selectors...)
+	}
+}
+
+// RemoveSelectors removes the elements matching the selectors before capturing.
+func RemoveSelectors(selectors ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.RemoveSelectors = append(opts.RemoveSelectors, selectors...)
+	}
+}
+
+// Headers sets extra HTTP headers sent with every request of the page.
+func Headers(headers map[string]string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Headers = headers
+	}
+}
+
+// pageRule returns the scripts, style sheets, selectors, headers and viewport
+// of the options and of the rules matching the host, the viewport is only
+// emulated if set by the configuration or the rules. Headers of the rules
+// override the ones of the options, and the viewport of the exact host is
+// preferred to the one of its domain. The wait-for selector is not merged,
+// see waitForRules.
+func pageRule(u *url.URL, options ScreenshotOptions) Rule {
+	// Copy the slices of the options which may be shared by captures.
+	rule := Rule{
+		BeforeNavigate: append([]string(nil), options.BeforeNavigate...),
+		AfterLoad:      append([]string(nil), options.AfterLoad...),
+		BeforeCapture:  append([]string(nil), options.BeforeCapture...),
+		CSS:            append([]string(nil), options.CSS...),
+		Hide:           append([]string(nil), options.HideSelectors...),
+		Remove:         append([]string(nil), options.RemoveSelectors...),
+		Headers:        make(map[string]string),
+	}
+	if options.viewport != nil {
+		rule.Viewport = options.viewport
+	}
+	for name, value := range options.Headers {
+		rule.Headers[name] = value
+	}
+	rules := options.Rules.Lookup(u.Hostname())
+	for i := len(rules) - 1; i >= 0; i-- {
+		// The rule of the exact host comes first, let it win.
+		r := rules[i]
+		for name, value := range r.Headers {
+			rule.Headers[name] = value
+		}
+		if r.Viewport != nil {
+			rule.Viewport = r.Viewport
+		}
+	}
+	for _, r := range rules {
+		rule.BeforeNavigate = append(rule.BeforeNavigate, r.BeforeNavigate...)
+		rule.AfterLoad = append(rule.AfterLoad, r.AfterLoad...)
+		rule.BeforeCapture = append(rule.BeforeCapture, r.BeforeCapture...)
+		rule.CSS = append(rule.CSS, r.CSS...)
+		rule.Hide = append(rule.Hide, r.Hide...)
+		rule.Remove = append(rule.Remove, r.Remove...)
+	}
+	return rule
+}
+
+// setHeaders sends the extra HTTP headers with every request of the page.
+func setHeaders(headers map[string]string) chromedp.Action {
+	if len(headers) == 0 {
+		return chromedp.Tasks{}
+	}
+
+	h := make(network.Headers, len(headers))
+	for name, value := range headers {
+		h[name] = value
+	}
+	return network.SetExtraHTTPHeaders(h)
+}
+
+// emulateViewport emulates the screen of the viewport, if any.
+func emulateViewport(vp *Viewport) chromedp.Action {
+	if vp == nil || vp.Width <= 0 || vp.Height <= 0 {
+		return chromedp.Tasks{}
+	}
+
+	var opts []chromedp.EmulateViewportOption
+	if vp.ScaleFactor > 0 {
+		opts = append(opts, chromedp.EmulateScale(vp.ScaleFactor))
+	}
+	if vp.Mobile {
+		opts = append(opts, chromedp.EmulateMobile)
+	}
+	return chromedp.EmulateViewport(vp.Width, vp.Height, opts...)
+}
+
+// waitForRules waits for the wait-for selectors of the rules, a selector
+// which does not appear in time is not an error.
+func waitForRules(rules []Rule) chromedp.Action {
+	var tasks chromedp.Tasks
+	for _, rule := range rules {
+		if rule.WaitFor == "" {
+			continue
+		}
+		sel, timeout := rule.WaitFor, rule.WaitTimeout
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		tasks = append(tasks, chromedp.ActionFunc(func(ctx context.Context) error {
+			tctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := chromedp.WaitReady(sel, chromedp.ByQuery).Do(tctx)
+			if err != nil && ctx.Err() == nil {
+				return nil
+			}
+			return err
+		}))
+	}
+	return tasks
+}
+
+// hideElements hides the elements matching the selectors by a style sheet,
+// which also applies to elements rendered later.
+func hideElements(selectors []string) chromedp.Action {
+	var css []string
+	for _, sel := range selectors {
+		css = append(css, fmt.Sprintf("%s { display: none !important; }", sel))
+	}
+	return injectCSS(css)
+}
+
+// removeElements removes the elements matching the selectors, and restores
+// scrolling of the page which is usually locked by modal overlays.
+func removeElements(selectors []string) chromedp.Action {
+	if len(selectors) == 0 {
+		return chromedp.Tasks{}
+	}
+
+	const script = `(selectors) => {
+  let removed = 0;
+  for (const sel of selectors) {
+    try {
+      document.querySelectorAll(sel).forEach((el) => { el.remove(); removed++; });
+    } catch (_) {}
+  }
+  if (removed > 0) {
+    for (const el of [document.documentElement, document.body]) {
+      if (el && getComputedStyle(el).overflow === 'hidden') el.style.setProperty('overflow', 'visible', 'important');
+    }
+  }
+  return removed;
+}`
+
+	arg, err := json.Marshal(selectors)
+	if err != nil {
+		return chromedp.Tasks{}
+	}
+	return chromedp.Evaluate(fmt.Sprintf("(%s)(%s)", script, arg), nil)
+}
+
+func addScriptsOnNewDocument(scripts []string) chromedp.Action {
+	if len(scripts) == 0 {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, script := range scripts {
+			if _, err := page.AddScriptToEvaluateOnNewDocument(script).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// evaluateScripts evaluates the scripts in order, waiting for the returned
+// promises. Exceptions thrown by the scripts are ignored.
+func evaluateScripts(scripts []string) chromedp.Action {
+	var tasks chromedp.Tasks
+	for _, script := range scripts {
+		script = fmt.Sprintf("(async () => {\n try{ \n%s\n }catch(_){};\n return true;\n})()", script)
+		tasks = append(tasks, chromedp.Evaluate(script, nil, chromedp.EvalIgnoreExceptions, awaitPromise))
+	}
+	return tasks
+}
+
+// injectCSS appends the style sheets to the head of the page.
+func injectCSS(css []string) chromedp.Action {
+	const script = `(css) => {
+  const style = document.createElement('style');
+  style.setAttribute('data-screenshot', '');
+  style.textContent = css;
+  (document.head || document.documentElement).appendChild(style);
+}`
+
+	var tasks chromedp.Tasks
+	for _, sheet := range css {
+		arg, err := json.Marshal(sheet)
+		if err != nil {
+			continue
+		}
+		tasks = append(tasks, chromedp.Evaluate(fmt.Sprintf("(%s)(%s)", script, arg), nil))
+	}
+	return tasks
+}
+
+func awaitPromise(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithAwaitPromise(true)
+}
@@ -0,0 +1,148 @@
+package screenshot
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestImportRules(t *testing.T) {
+	f := `rules:
+  example.com:
+    before-navigate:
+      - 'window.foo = 1;'
+    after-load:
+      - 'document.querySelector(".popup").remove();'
+    before-capture:
+      - 'window.scrollTo(0, 0);'
+    css:
+      - '.banner { display: none; }'
+  www.example.org:
+    after-load:
+      - 'window.bar = 1;'`
+	rules, err := ImportRules(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(rules); n != 2 {
+		t.Fatalf("unexpected number of rules got %d instead of %d", n, 2)
+	}
+	if n := len(rules.Lookup("news.example.com")); n != 1 {
+		t.Errorf("unexpected rules of subdomain got %d instead of %d", n, 1)
+	}
+	if n := len(rules.Lookup("example.org")); n != 0 {
+		t.Errorf("unexpected rules of parent domain got %d instead of %d", n, 0)
+	}
+
+	u, _ := url.Parse("https://example.com/")
+	rule := pageRule(u, ScreenshotOptions{Rules: rules, AfterLoad: []string{"window.zoo = 1;"}})
+	if n := len(rule.AfterLoad); n != 2 {
+		t.Errorf("unexpected number of after-load scripts got %d instead of %d", n, 2)
+	}
+	if n := len(rule.BeforeNavigate) + len(rule.BeforeCapture) + len(rule.CSS); n != 3 {
+		t.Errorf("unexpected number of scripts and style sheets got %d instead of %d", n, 3)
+	}
+}
+
+func TestImportRulesDir(t *testing.T) {
+	dirname, err := os.MkdirTemp(os.TempDir(), "screenshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirname)
+
+	files := map[string]string{
+		"rules.yaml":      "rules:\n  example.com:\n    after-load:\n      - 'window.foo = 1;'",
+		"example.com.js":  "window.bar = 1;",
+		"example.com.css": ".banner { display: none; }",
+		"README.md":       "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(path.Join(dirname, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := ImportRulesDir(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := rules["example.com"]
+	if n := len(rule.AfterLoad); n != 2 {
+		t.Errorf("unexpected number of after-load scripts got %d instead of %d", n, 2)
+	}
+	if n := len(rule.CSS); n != 1 {
+		t.Errorf("unexpected number of style sheets got %d instead of %d", n, 1)
+	}
+}
+
+func TestImportRulesSelectors(t *testing.T) {
+	f := `rules:
+  example.com:
+    hide:
+      - 'header.sticky'
+    remove:
+      - '#chat-widget'
+      - '.modal-overlay'
+    wait-for: '.modal-overlay'
+    wait-timeout: 3s`
+	rules, err := ImportRules(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := rules["example.com"]
+	if rule.WaitFor != ".modal-overlay" || rule.WaitTimeout != 3*time.Second {
+		t.Errorf("unexpected wait-for got %s in %s", rule.WaitFor, rule.WaitTimeout)
+	}
+
+	u, _ := url.Parse("https://www.example.com/")
+	merged := pageRule(u, ScreenshotOptions{Rules: rules, HideSelectors: []string{"#ads"}})
+	if n := len(merged.Hide); n != 2 {
+		t.Errorf("unexpected number of hidden selectors got %d instead of %d", n, 2)
+	}
+	if n := len(merged.Remove); n != 2 {
+		t.Errorf("unexpected number of removed selectors got %d instead of %d", n, 2)
+	}
+}
+
+func TestPageRuleHeadersViewport(t *testing.T) {
+	rules := Rules{
+		"example.com": {
+			Headers:  map[string]string{"Accept-Language": "en-US", "X-Foo": "1"},
+			Viewport: &Viewport{Width: 1280, Height: 720},
+		},
+		"m.example.com": {
+			Headers:  map[string]string{"Accept-Language": "de-DE"},
+			Viewport: &Viewport{Width: 375, Height: 812, Mobile: true},
+		},
+	}
+	opts := ScreenshotOptions{Rules: rules, viewport: &Viewport{Width: 800, Height: 600}, Headers: map[string]string{"X-Bar": "2"}}
+
+	u, _ := url.Parse("https://m.example.com/")
+	rule := pageRule(u, opts)
+	if got := rule.Headers["Accept-Language"]; got != "de-DE" {
+		t.Errorf("unexpected header got %q instead of %q", got, "de-DE")
+	}
+	if n := len(rule.Headers); n != 3 {
+		t.Errorf("unexpected number of headers got %d instead of %d", n, 3)
+	}
+	if rule.Viewport == nil || rule.Viewport.Width != 375 {
+		t.Errorf("unexpected viewport got %+v", rule.Viewport)
+	}
+
+	u, _ = url.Parse("https://example.org/")
+	rule = pageRule(u, opts)
+	if rule.Viewport == nil || rule.Viewport.Width != 800 {
+		t.Errorf("unexpected viewport got %+v", rule.Viewport)
+	}
+
+	// The size of the options is not emulated.
+	opts = ScreenshotOptions{Width: 800, Height: 600, Mobile: true}
+	if rule = pageRule(u, opts); rule.Viewport != nil {
+		t.Errorf("unexpected viewport of the options got %+v", rule.Viewport)
+	}
+}
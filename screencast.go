@@ -0,0 +1,202 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// Limits of the screencast to keep the animation reasonably small.
+	maxScreencastFrames = 300
+	maxScreencastWidth  = 800
+	maxScreencastHeight = 600
+)
+
+// ScreencastFrame represents a frame of the screencast, the data is a JPEG image.
+type ScreencastFrame struct {
+	Data      []byte
+	Timestamp time.Time
+}
+
+// screencast records the frames of the page, once the limit of frames is
+// reached every other frame is dropped and the later frames are sampled
+// at twice the interval, so that the frames span the whole page load.
+type screencast struct {
+	mu     sync.Mutex
+	frames []ScreencastFrame
+	last   *ScreencastFrame // Last frame received, kept to show the loaded page.
+	seen   int              // Number of frames received.
+	stride int              // Interval of the frames recorded.
+
+	cancel context.CancelFunc // Removes the frame listener.
+}
+
+func (s *screencast) add(ev *page.EventScreencastFrame) {
+	buf, err := base64.StdEncoding.DecodeString(ev.Data)
+	if err != nil {
+		return
+	}
+	ts := time.Now()
+	if ev.Metadata != nil && ev.Metadata.Timestamp != nil {
+		ts = ev.Metadata.Timestamp.Time()
+	}
+
+	s.push(ScreencastFrame{Data: buf, Timestamp: ts})
+}
+
+func (s *screencast) push(frame ScreencastFrame) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stride == 0 {
+		s.stride = 1
+	}
+	s.last = &frame
+	s.seen++
+	if (s.seen-1)%s.stride != 0 {
+		return
+	}
+	s.frames = append(s.frames, frame)
+	if len(s.frames) >= maxScreencastFrames {
+		kept := s.frames[:0]
+		for i := 0; i < len(s.frames); i += 2 {
+			kept = append(kept, s.frames[i])
+		}
+		s.frames = kept
+		s.stride *= 2
+	}
+}
+
+// Frames returns a copy of the frames recorded, ending with the last frame
+// received.
+func (s *screencast) Frames() []ScreencastFrame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frames := make([]ScreencastFrame, len(s.frames), len(s.frames)+1)
+	copy(frames, s.frames)
+	if s.last != nil && (s.seen-1)%s.stride != 0 {
+		frames = append(frames, *s.last)
+	}
+	return frames
+}
+
+// stop removes the frame listener.
+func (s *screencast) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+func startScreencast(rec *screencast, options ScreenshotOptions) chromedp.Action {
+	if !options.Screencast {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			lctx, cancel := context.WithCancel(ctx)
+			rec.mu.Lock()
+			rec.cancel = cancel
+			rec.mu.Unlock()
+			chromedp.ListenTarget(lctx, func(ev interface{}) {
+				if ev, ok := ev.(*page.EventScreencastFrame); ok {
+					rec.add(ev)
+					go func() {
+						_ = chromedp.Run(ctx, page.ScreencastFrameAck(ev.SessionID))
+					}()
+				}
+			})
+			return page.StartScreencast().
+				WithFormat(page.ScreencastFormatJpeg).
+				WithQuality(80).
+				WithMaxWidth(maxScreencastWidth).
+				WithMaxHeight(maxScreencastHeight).
+				Do(ctx)
+		}),
+	}
+}
+
+func stopScreencast[T As](res *T, frames *[]ScreencastFrame, rec *screencast, options ScreenshotOptions) chromedp.Action {
+	if !options.Screencast {
+		return chromedp.Tasks{}
+	}
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			err := page.StopScreencast().Do(ctx)
+			rec.stop()
+			if err != nil {
+				return err
+			}
+			if options.ScreencastFrames {
+				*frames = rec.Frames()
+			}
+			buf, err := encodeGIF(rec.Frames())
+			if err != nil {
+				return err
+			}
+			return setResult(res, buf, options.Files.Screencast)
+		}),
+	}
+}
+
+// encodeGIF assembles frames to an animated GIF, the delay of each frame
+// is derived from the timestamp of the next frame.
+func encodeGIF(frames []ScreencastFrame) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no screencast frames received")
+	}
+
+	anim := &gif.GIF{}
+	for i, f := range frames {
+		img, err := jpeg.Decode(bytes.NewReader(f.Data))
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		paletted := image.NewPaletted(bounds, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+
+		// Delay is in 100ths of a second, show the last frame for a second.
+		delay := 100
+		if i+1 < len(frames) {
+			delay = int(frames[i+1].Timestamp.Sub(f.Timestamp) / (10 * time.Millisecond))
+		}
+		if delay < 1 {
+			delay = 1
+		}
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+
+		if bounds.Dx() > anim.Config.Width {
+			anim.Config.Width = bounds.Dx()
+		}
+		if bounds.Dy() > anim.Config.Height {
+			anim.Config.Height = bounds.Dy()
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package screenshot
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+func TestEncodeGIF(t *testing.T) {
+	now := time.Now()
+	var frames []ScreencastFrame
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+		img.Set(i, i, color.RGBA{R: 255, A: 255})
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, ScreencastFrame{Data: buf.Bytes(), Timestamp: now.Add(time.Duration(i) * 200 * time.Millisecond)})
+	}
+
+	buf, err := encodeGIF(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(anim.Image); n != 3 {
+		t.Fatalf("unexpected number of frames got %d instead of %d", n, 3)
+	}
+	if delay := anim.Delay[0]; delay != 20 {
+		t.Errorf("unexpected delay of first frame got %d instead of %d", delay, 20)
+	}
+
+	if _, err := encodeGIF(nil); err == nil {
+		t.Error("unexpected encode empty frames without error")
+	}
+}
+
+func TestScreencastSampling(t *testing.T) {
+	rec := &screencast{}
+	now := time.Now()
+	n := 3*maxScreencastFrames + 7
+	for i := 0; i < n; i++ {
+		rec.push(ScreencastFrame{Data: []byte{byte(i)}, Timestamp: now.Add(time.Duration(i) * time.Millisecond)})
+	}
+
+	frames := rec.Frames()
+	if len(frames) > maxScreencastFrames+1 {
+		t.Fatalf("unexpected number of frames got %d, want at most %d", len(frames), maxScreencastFrames+1)
+	}
+	if !frames[0].Timestamp.Equal(now) {
+		t.Errorf("unexpected first frame at %s", frames[0].Timestamp.Sub(now))
+	}
+	if last := frames[len(frames)-1]; !last.Timestamp.Equal(now.Add(time.Duration(n-1) * time.Millisecond)) {
+		t.Errorf("unexpected last frame at %s", last.Timestamp.Sub(now))
+	}
+	for i := 1; i < len(frames); i++ {
+		if !frames[i].Timestamp.After(frames[i-1].Timestamp) {
+			t.Fatalf("frames out of order at %d", i)
+		}
+	}
+	// The frames span the whole recording rather than its beginning.
+	if mid := frames[len(frames)/2].Timestamp.Sub(now); mid < time.Duration(n/3)*time.Millisecond {
+		t.Errorf("unexpected frames sampled, the middle one is at %s", mid)
+	}
+
+	frames[0].Data = nil
+	if rec.Frames()[0].Data == nil {
+		t.Error("unexpected frames shared with the recorder")
+	}
+}
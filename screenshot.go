@@ -2,14 +2,11 @@ package screenshot
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,9 +14,9 @@ import (
 	"github.com/chromedp/cdproto/browser"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/inspector"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/cdproto/storage"
 	"github.com/chromedp/chromedp"
 	"github.com/pkg/errors"
@@ -58,122 +55,71 @@ func (p Path) String() string {
 type Screenshots[T As] struct {
 	URL   string
 	Title string
+
+	// Status code and headers of the main document.
+	StatusCode int
+	Header     http.Header
+
 	Image T
 	HTML  T
 	PDF   T
 	HAR   T
 
-	// Total bytes of resources
-	DataLength int64
-}
+	// Accessibility tree as JSON, available if AccessibilityTree is enabled.
+	AXTree T
+	// Obvious accessibility issues computed from the accessibility tree.
+	AXIssues []AXIssue
 
-// Screenshoter is a webpage screenshot interface.
-type Screenshoter[T As] interface {
-	Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error)
-}
+	// DOM snapshot with layout and computed styles as JSON, available if DOMSnapshot is enabled.
+	DOM T
 
-type chromeRemoteScreenshoter[T As] struct {
-	opts []chromedp.RemoteAllocatorOption
+	// Animated GIF of the page load, available if Screencast is enabled.
+	Screencast T
+	// Frames of the screencast, available if ScreencastFrames is enabled.
+	Frames []ScreencastFrame
 
-	url string
-}
-
-// NewChromeRemoteScreenshoter creates a Screenshoter backed by Chrome DevTools Protocol.
-// The addr is the headless chrome websocket debugger endpoint, such as 127.0.0.1:9222.
-func NewChromeRemoteScreenshoter[T As](addr string) (res Screenshoter[T], err error) {
-	if strings.HasPrefix(addr, "wss://") || strings.HasPrefix(addr, "ws://") {
-		// added https://github.com/chromedp/chromedp/pull/1184
-		res = &chromeRemoteScreenshoter[T]{
-			opts: []chromedp.RemoteAllocatorOption{chromedp.NoModifyURL},
-			url:  addr,
-		}
-	} else {
-		// Due to issue#505 (https://github.com/chromedp/chromedp/issues/505),
-		// chrome restricts the host must be IP or localhost, we should rewrite the url.
-		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/json/version", addr), nil)
-		if err != nil {
-			return res, err
-		}
-		req.Host = "localhost"
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return res, err
-		}
-		defer resp.Body.Close()
-
-		var result map[string]interface{}
-		if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return res, err
-		}
+	// Total bytes of resources
+	DataLength int64
 
-		res = &chromeRemoteScreenshoter[T]{
-			url: strings.Replace(result["webSocketDebuggerUrl"].(string), "localhost", addr, 1),
-		}
-	}
+	// Performance audit of the capture, available if AuditPerformance is enabled.
+	Performance *Performance
 
-	return res, nil
-}
+	// Cookie consent banners dismissed before capturing, see Consent.
+	Consent []ConsentResult
 
-func (s *chromeRemoteScreenshoter[T]) Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
-	if s.url == "" {
-		return nil, fmt.Errorf("can't connect to headless browser")
-	}
+	// Whether scrolling stopped before the end of the page due to MaxScrolls,
+	// MaxPageHeight, LoadMore or running out of time.
+	Truncated bool
 
-	ctx, cancel := chromedp.NewRemoteAllocator(ctx, s.url, s.opts...)
-	defer cancel()
+	// Attempts of the capture, available if captured by a Screenshoter returned by Retry.
+	Attempts []Attempt
 
-	return screenshotStart[T](ctx, input, options...)
+	// Cookies of the browser after the capture, available if ExportCookies is enabled.
+	Cookies []Cookie
 }
 
-func Screenshot[T As](ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
-	if _, err := exec.LookPath(helper.FindChromeExecPath()); err != nil {
-		return nil, err
-	}
-
-	// https://github.com/chromedp/chromedp/blob/b56cd66f9cebd6a1fa1283847bbf507409d48225/allocate.go#L53
-	var allocOpts = append(
-		chromedp.DefaultExecAllocatorOptions[:],
-		// chromedp.CombinedOutput(log.Writer()),
-		chromedp.WSURLReadTimeout(wsURLReadTimeout()),
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("ignore-certificate-errors", true),
-		chromedp.Flag("allow-running-insecure-content", true),
-		chromedp.Flag("no-default-browser-check", true),
-		chromedp.Flag("disable-notifications", true),
-		chromedp.Flag("disable-web-security", true),
-		chromedp.Flag("disable-webgl", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-first-run", true),
-		chromedp.Flag("proxy-server", proxyServer()),
-	)
-	f := "false"
-	if noHeadless := os.Getenv("CHROMEDP_NO_HEADLESS"); noHeadless != "" && noHeadless != f {
-		allocOpts = append(allocOpts, chromedp.Flag("headless", false))
-	}
-	if noSandbox := os.Getenv("CHROMEDP_NO_SANDBOX"); noSandbox != "" && noSandbox != f {
-		allocOpts = append(allocOpts, chromedp.NoSandbox)
-	}
-	if disableGPU := os.Getenv("CHROMEDP_DISABLE_GPU"); disableGPU != "" && disableGPU != f {
-		allocOpts = append(allocOpts, chromedp.DisableGPU)
-	}
-	if userAgent := os.Getenv("CHROMEDP_USER_AGENT"); userAgent != "" {
-		allocOpts = append(allocOpts, chromedp.UserAgent(userAgent))
-	} else {
-		allocOpts = append(allocOpts, chromedp.UserAgent(defaultUA))
-	}
-	dir, err := os.MkdirTemp(os.TempDir(), "chromedp-runner-*")
-	if err == nil && dir != "" {
-		defer os.RemoveAll(dir)
-		allocOpts = append(allocOpts, chromedp.UserDataDir(dir))
-	}
-	ctx, cancel := chromedp.NewExecAllocator(ctx, allocOpts...)
-	defer cancel()
-
-	return screenshotStart[T](ctx, input, options...)
+// Screenshoter is a webpage screenshot interface.
+type Screenshoter[T As] interface {
+	Screenshot(ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error)
 }
 
-func screenshotStart[T As](ctx context.Context, input *url.URL, options ...ScreenshotOption) (shot *Screenshots[T], err error) {
-	var browserOpts []chromedp.ContextOption
+// Screenshot captures the page in a browser launched by DefaultLaunchOptions,
+// see NewChromeScreenshoter to configure the browser.
+func Screenshot[T As](ctx context.Context, input *url.URL, options ...ScreenshotOption) (*Screenshots[T], error) {
+	return NewChromeScreenshoter[T]().Screenshot(ctx, input, options...)
+}
+
+// screenshotStart captures the page in a new tab created with the context options,
+// e.g. in a new browser context. If the browser fails to set up
+// the tab or to navigate to the page, it returns a *StageError without result;
+// if some of the following stages fail, it returns the artifacts produced by
+// the others alongside a *CaptureError. If the status code of the main document
+// is rejected by FailOnStatus, it returns the status and headers alongside a
+// *HTTPStatusError without capturing.
+func screenshotStart[T As](ctx context.Context, input *url.URL, ctxOpts []chromedp.ContextOption, options ...ScreenshotOption) (shot *Screenshots[T], err error) {
+	// The browser lost the connection if the tab is cancelled but not the caller.
+	parent := ctx
+	browserOpts := append([]chromedp.ContextOption(nil), ctxOpts...)
 	if debug := os.Getenv("CHROMEDP_DEBUG"); debug != "" && debug != "false" {
 		browserOpts = append(browserOpts, chromedp.WithDebugf(log.Printf))
 	}
@@ -188,6 +134,11 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 		opts.Format = page.CaptureScreenshotFormatJpeg
 	}
 
+	started := time.Now()
+	defer func() {
+		opts.emit(Finished{URL: input.String(), Duration: time.Since(started), Err: err})
+	}()
+
 	// run a no-op action to allocate the browser
 	// if err := chromedp.Run(ctx, chromedp.ActionFunc(func(_ context.Context) error {
 	// 	return nil
@@ -196,12 +147,40 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 	// }
 
 	url := convertURI(input)
+	rule := pageRule(input, opts)
 	var img T
 	var pdf T
 	var har T
 	var raw T
+	var axTree T
+	var axIssues []AXIssue
+	var domSnapshot T
+	var cast T
+	var frames []ScreencastFrame
+	rec := &screencast{}
+	defer rec.stop()
+	var consent []ConsentResult
+	var truncated bool
+	var cookies []Cookie
+	var iframes *frameTargets
+	if opts.Consent != ConsentOff {
+		iframes = watchFrames(ctx)
+		defer iframes.release()
+	}
 	var title string
 	var dataLength int64
+	var nRequest, nResponse, nFailure int64
+	var crashed int32
+	// Set once navigating to the page, the lifecycle events before it are of
+	// the blank page seeding the storage.
+	var navigating int32
+	var statusCode int
+	var header http.Header
+	docMu := sync.Mutex{}
+	var perf *Performance
+	if opts.Performance {
+		perf = &Performance{}
+	}
 
 	nRequests := &sync.Map{}
 	nResponses := &sync.Map{}
@@ -217,6 +196,7 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 				_ = chromedp.Run(ctx, page.HandleJavaScriptDialog(true))
 			}()
 		case *network.EventRequestWillBeSent:
+			atomic.AddInt64(&nRequest, 1)
 			wg.Add(1)
 			go func(r *network.EventRequestWillBeSent) {
 				defer wg.Done()
@@ -228,6 +208,13 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 				mu.Unlock()
 			}(v)
 		case *network.EventResponseReceived:
+			atomic.AddInt64(&nResponse, 1)
+			if v.Type == network.ResourceTypeDocument && isMainFrame(ctx, v.FrameID) {
+				docMu.Lock()
+				statusCode = int(v.Response.Status)
+				header = responseHeader(v.Response.Headers)
+				docMu.Unlock()
+			}
 			wg.Add(1)
 			go func(r *network.EventResponseReceived) {
 				defer wg.Done()
@@ -245,7 +232,9 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 						return err
 					}),
 					chromedp.ActionFunc(func(ctx context.Context) error {
-						cookies, err = storage.GetCookies().Do(ctx)
+						// The cookies of the browser context of the capture, which
+						// is not the default one on remote browsers.
+						cookies, err = storage.GetCookies().WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).Do(ctx)
 						return err
 					}),
 				)
@@ -260,99 +249,158 @@ func screenshotStart[T As](ctx context.Context, input *url.URL, options ...Scree
 			// 	go func() {
 			// 		lf := v.(*network.EventLoadingFinished)
 			// 	}()
-			// case *network.EventLoadingFailed:
-			// 	// Fired when HTTP request has failed to load.
-			// 	go func() {
-			// 		lf := v.(*network.EventLoadingFailed)
-			// 	}()
+		case *network.EventLoadingFailed:
+			// Fired when HTTP request has failed to load.
+			atomic.AddInt64(&nFailure, 1)
+		case *page.EventLifecycleEvent:
+			if atomic.LoadInt32(&navigating) == 1 && isMainFrame(ctx, v.FrameID) {
+				opts.emit(LifecycleEvent{URL: input.String(), Name: v.Name})
+			}
+		case *inspector.EventTargetCrashed:
+			atomic.StoreInt32(&crashed, 1)
 		}
 	})
 
-	captureAction := screenshotAction[T](&img, opts)
-	exportHTML := exportHTML[T](&raw, opts)
-	saveAsPDF := printPDF[T](&pdf, opts)
+	stageError := func(stage Stage, err error) error {
+		if atomic.LoadInt32(&crashed) == 1 || (parent.Err() == nil && ctx.Err() != nil) {
+			err = fmt.Errorf("%w: %v", ErrBrowserCrashed, err)
+		}
+		return &StageError{Stage: stage, Err: err}
+	}
+
 	if err := chromedp.Run(ctx, chromedp.Tasks{
 		dom.Enable(),
 		page.Enable(),
+		seedStorage(input, opts),
 		network.Enable(),
+		proxyAuth(opts),
+		setHeaders(rule.Headers),
+		emulateViewport(rule.Viewport),
 		stealth(),
+		addScriptsOnNewDocument(rule.BeforeNavigate),
+		observePerformance(opts),
 		setCookies(opts),
-		setLocalStorage(input, opts),
-		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorDeny),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorDeny).
+				WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).
+				Do(ctx)
+		}),
+	}); err != nil {
+		return nil, stageError(StageSetup, err)
+	}
+	opts.emit(BrowserAllocated{URL: input.String()})
+
+	var errs []error
+	if err := chromedp.Run(ctx, startScreencast(rec, opts)); err != nil {
+		errs = append(errs, stageError(StageScreencast, err))
+	}
+	atomic.StoreInt32(&navigating, 1)
+	opts.emit(NavigationStarted{URL: input.String()})
+	if err := chromedp.Run(ctx, chromedp.Tasks{
 		navigateAndWaitFor(url, "networkAlmostIdle"),
 		chromedp.Sleep(time.Second),
 		evaluate(input),
-		scrollToBottom(ctx),
-		chromedp.Title(&title),
-		captureAction,
-		exportHTML,
-		saveAsPDF,
-		chromedp.ResetViewport(),
-		chromedp.Sleep(time.Second),
-		closePageAction(),
-	}); err != nil && !errors.Is(err, chromedp.ErrPollingTimeout) {
-		return shot, err
+		injectCSS(rule.CSS),
+		evaluateScripts(rule.AfterLoad),
+	}); err != nil {
+		return nil, stageError(StageNavigate, err)
+	}
+	docMu.Lock()
+	shot = &Screenshots[T]{URL: revertURI(url), StatusCode: statusCode, Header: header}
+	docMu.Unlock()
+	if rejectStatus(shot.StatusCode, opts.FailOnStatus) {
+		return shot, stageError(StageNavigate, &HTTPStatusError{URL: shot.URL, StatusCode: shot.StatusCode})
+	}
+
+	// Each stage produces its own artifact, a failed stage does not
+	// prevent the following ones unless the page is gone.
+	stages := []struct {
+		name     Stage
+		action   chromedp.Action
+		artifact *T
+	}{
+		{StageConsent, handleConsent(&consent, iframes, opts), nil},
+		{StageActions, runActions(opts), nil},
+		{StageScroll, scrollToBottom(ctx, input.String(), &truncated, opts), nil},
+		{StageBeforeCapture, chromedp.Tasks{
+			waitForRules(opts.Rules.Lookup(input.Hostname())),
+			evaluateScripts(rule.BeforeCapture),
+			hideElements(rule.Hide),
+			removeElements(rule.Remove),
+		}, nil},
+		{StageScreencast, stopScreencast[T](&cast, &frames, rec, opts), &cast},
+		{StageTitle, chromedp.Title(&title), nil},
+		{StagePerformance, collectPerformance(perf, opts), nil},
+		{StageScreenshot, screenshotAction[T](&img, opts), &img},
+		{StageAccessibility, accessibilityTree[T](&axTree, &axIssues, opts), &axTree},
+		{StageHTML, exportHTML[T](&raw, opts), &raw},
+		{StageDOMSnapshot, captureDOMSnapshot[T](&domSnapshot, opts), &domSnapshot},
+		{StagePDF, printPDF[T](&pdf, opts), &pdf},
+		{StageCookies, exportCookies(&cookies, opts), nil},
+	}
+	for _, stage := range stages {
+		if err := chromedp.Run(ctx, stage.action); err != nil {
+			errs = append(errs, stageError(stage.name, err))
+			if ctx.Err() != nil || atomic.LoadInt32(&crashed) == 1 {
+				break
+			}
+			continue
+		}
+		if stage.artifact != nil {
+			if size := artifactSize(*stage.artifact); size > 0 {
+				opts.emit(ArtifactProduced{URL: input.String(), Stage: stage.name, Size: size})
+			}
+		}
 	}
+	// Failures of restoring the page do not affect the artifacts.
+	_ = chromedp.Run(ctx, chromedp.ResetViewport(), chromedp.Sleep(time.Second), closePageAction())
 
 	// Wait for all the go routines to complete
 	wg.Wait()
 
-	_ = compose[T](requestsID, nRequests, nResponses, opts, url, &har)
+	if err := compose[T](requestsID, nRequests, nResponses, opts, url, &har); err != nil {
+		errs = append(errs, stageError(StageHAR, err))
+	} else if size := artifactSize(har); size > 0 {
+		opts.emit(ArtifactProduced{URL: input.String(), Stage: StageHAR, Size: size})
+	}
+	if perf != nil {
+		perf.Requests = atomic.LoadInt64(&nRequest)
+		perf.Responses = atomic.LoadInt64(&nResponse)
+		perf.Failures = atomic.LoadInt64(&nFailure)
+		perf.DataLength = atomic.LoadInt64(&dataLength)
+		if err := writePerformance(perf, opts); err != nil {
+			errs = append(errs, stageError(StagePerformance, err))
+		}
+	}
 	shot = &Screenshots[T]{
-		URL:   revertURI(url),
+		URL:   shot.URL,
 		PDF:   pdf,
 		HAR:   har,
 		HTML:  raw,
 		Image: img,
 		Title: title,
 
-		DataLength: atomic.LoadInt64(&dataLength),
-	}
+		StatusCode: shot.StatusCode,
+		Header:     shot.Header,
 
-	return shot, nil
-}
+		AXTree:   axTree,
+		AXIssues: axIssues,
+		DOM:      domSnapshot,
 
-// https://github.com/chromedp/chromedp/blob/875d6f4a3453149639d7fa83a2fa473b743fc33f/poll.go#L88-L127
-func scrollToBottom(ctx context.Context) chromedp.Action {
-	// This function script scrolls down 150px once. It returns a boolean for `chromedp.PollFunction`
-	// to determine whether to execute next if the current height is less than the total height.
-	// If an exception occurs, it will return true for termilate.
-	//
-	// Due to accuracy, the `currentHeight` may always be less than `the'scrollHeight`, add 1px
-	// `currentHeight` to ensure scrolled to bottom.
-	const script = `()=>{
-    let distance = 150;
-    let scrollHeight = 0;
-    let currentHeight = 0;
-
-    try {
-        scrollHeight = document.documentElement.scrollHeight || document.body.scrollHeight;
-        currentHeight = window.innerHeight + window.pageYOffset + 1;
-    } catch (e) {
-        return true;
-    }
-
-    window.scrollBy(0, distance);
-    if (currentHeight >= scrollHeight) {
-        return true;
-    }
-    return false;
-}`
-
-	timeout := 15 * time.Second
-	deadline, ok := ctx.Deadline()
-	if ok {
-		timeout = deadline.Sub(time.Now())
-		idle := 5 * time.Second
-		if timeout > idle {
-			timeout -= idle
-		}
+		Screencast: cast,
+		Frames:     frames,
+
+		DataLength:  atomic.LoadInt64(&dataLength),
+		Performance: perf,
+		Consent:     consent,
+		Truncated:   truncated,
+		Cookies:     cookies,
 	}
 
-	// Scroll down to the bottom line by line, which is controlled by `chromedp.WithPollingInterval`.
-	return chromedp.Tasks{
-		chromedp.PollFunction(script, nil, chromedp.WithPollingTimeout(timeout), chromedp.WithPollingInterval(150*time.Millisecond)),
+	if len(errs) > 0 {
+		return shot, &CaptureError{Errors: errs}
 	}
+	return shot, nil
 }
 
 func setCookies(options ScreenshotOptions) chromedp.Action {
@@ -364,11 +412,15 @@ func setCookies(options ScreenshotOptions) chromedp.Action {
 		chromedp.ActionFunc(func(ctx context.Context) (err error) {
 			for key := range options.Cookies {
 				cookie := options.Cookies[key]
-				expire := cdp.TimeSinceEpoch(cookie.Expires)
-				er := network.SetCookie(cookie.Name, cookie.Value).
+				params := network.SetCookie(cookie.Name, cookie.Value)
+				if !cookie.Expires.IsZero() {
+					// A session cookie if not set.
+					expire := cdp.TimeSinceEpoch(cookie.Expires)
+					params = params.WithExpires(&expire)
+				}
+				er := params.
 					WithDomain(cookie.Domain).
 					WithPath(cookie.Path).
-					WithExpires(&expire).
 					WithHTTPOnly(cookie.HTTPOnly).
 					WithSecure(cookie.Secure).
 					WithSameParty(cookie.SameParty).
@@ -384,31 +436,6 @@ func setCookies(options ScreenshotOptions) chromedp.Action {
 	}
 }
 
-func setLocalStorage(u *url.URL, options ScreenshotOptions) chromedp.Action {
-	if len(options.Storage) == 0 {
-		return chromedp.Tasks{}
-	}
-
-	return chromedp.Tasks{
-		chromedp.ActionFunc(func(ctx context.Context) (err error) {
-			for key := range options.Storage {
-				item := options.Storage[key]
-				if u.Host != item.Host {
-					continue
-				}
-				_, exp, er := runtime.Evaluate(fmt.Sprintf(`window.localStorage.setItem('%s', '%s')`, item.Key, item.Value)).Do(ctx)
-				if er != nil {
-					err = errors.Wrap(err, er.Error())
-				}
-				if exp != nil {
-					err = errors.Wrap(err, exp.Error())
-				}
-			}
-			return err
-		}),
-	}
-}
-
 // Note: this will override the viewport emulation settings.
 func screenshotAction[T As](res *T, options ScreenshotOptions) chromedp.Action {
 	return chromedp.Tasks{
@@ -426,6 +453,9 @@ func screenshotAction[T As](res *T, options ScreenshotOptions) chromedp.Action {
 			if options.MaxHeight > 0 && contentSize.Height > float64(options.MaxHeight) {
 				contentSize.Height = float64(options.MaxHeight)
 			}
+			if options.MaxPageHeight > 0 && contentSize.Height > float64(options.MaxPageHeight) {
+				contentSize.Height = float64(options.MaxPageHeight)
+			}
 			if options.MaxWidth > 0 && contentSize.Width > float64(options.MaxWidth) {
 				contentSize.Width = float64(options.MaxWidth)
 			}
@@ -445,16 +475,7 @@ func screenshotAction[T As](res *T, options ScreenshotOptions) chromedp.Action {
 			if err != nil {
 				return err
 			}
-			switch t := (interface{})(res).(type) {
-			case *Byte:
-				*t = buf
-			case *Path:
-				err = helper.WriteFile(options.Files.Image, buf, perm)
-				if err == nil {
-					*t = Path(options.Files.Image)
-				}
-			}
-			return err
+			return setResult(res, buf, options.Files.Image)
 		}),
 	}
 }
@@ -467,16 +488,10 @@ func printPDF[T As](res *T, options ScreenshotOptions) chromedp.Action {
 	return chromedp.Tasks{
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			buf, _, err := page.PrintToPDF().WithLandscape(true).WithPrintBackground(true).Do(ctx)
-			switch t := (interface{})(res).(type) {
-			case *Byte:
-				*t = buf
-			case *Path:
-				err = helper.WriteFile(options.Files.PDF, buf, perm)
-				if err == nil {
-					*t = Path(options.Files.PDF)
-				}
+			if err != nil {
+				return err
 			}
-			return err
+			return setResult(res, buf, options.Files.PDF)
 		}),
 	}
 }
@@ -497,17 +512,7 @@ func exportHTML[T As](res *T, options ScreenshotOptions) chromedp.Action {
 			if err != nil {
 				return err
 			}
-			buf := helper.String2Byte(raw)
-			switch t := (interface{})(res).(type) {
-			case *Byte:
-				*t = buf
-			case *Path:
-				err = helper.WriteFile(options.Files.HTML, buf, perm)
-				if err == nil {
-					*t = Path(options.Files.HTML)
-				}
-			}
-			return err
+			return setResult(res, helper.String2Byte(raw), options.Files.HTML)
 		}),
 	}
 }
@@ -537,10 +542,13 @@ func enableLifeCycleEvents() chromedp.ActionFunc {
 
 func navigateAndWaitFor(url string, eventName string) chromedp.ActionFunc {
 	return func(ctx context.Context) error {
-		_, _, _, err := page.Navigate(url).Do(ctx)
+		_, _, errorText, err := page.Navigate(url).Do(ctx)
 		if err != nil {
 			return err
 		}
+		if errorText != "" {
+			return &NavigationError{URL: revertURI(url), Code: errorText}
+		}
 
 		// timeout := 30 * time.Second
 		// ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -599,10 +607,72 @@ type ScreenshotOptions struct {
 	RawHTML  bool
 	DumpHAR  bool
 
+	Performance bool
+
+	AccessibilityTree bool
+
+	DOMSnapshot    bool
+	SnapshotStyles []string // Computed styles captured by DOMSnapshot.
+
+	Screencast       bool
+	ScreencastFrames bool
+
 	Files Files
 
 	Cookies []Cookie
 	Storage []LocalStorage
+
+	// Session storage and IndexedDB values of the origin of the page.
+	SessionStorage []LocalStorage
+	IndexedDB      []IndexedDBItem
+
+	// Whether the cookies of the browser are returned after the capture.
+	ExportCookies bool
+
+	// Status codes of the main document which fail the capture.
+	FailOnStatus []StatusRange
+
+	// Callback receiving the events of the capture.
+	OnEvent func(Event)
+
+	// Scripts and style sheets applied to the page, see also Rules.
+	BeforeNavigate []string
+	AfterLoad      []string
+	BeforeCapture  []string
+	CSS            []string
+
+	// Selectors of elements hidden or removed before capturing.
+	HideSelectors   []string
+	RemoveSelectors []string
+
+	Rules Rules
+
+	// Viewport emulated for the page by the configuration file, see Config.
+	viewport *Viewport
+
+	// Extra HTTP headers sent with every request of the page.
+	Headers map[string]string
+
+	// How cookie consent banners are handled before capturing.
+	Consent ConsentMode
+
+	// Scrolling to trigger lazy loading, see ScrollStep, ScrollInterval and MaxScrolls.
+	ScrollStep     int64
+	ScrollInterval time.Duration
+	MaxScrolls     int
+	MaxPageHeight  int64
+
+	// Selector of the load-more element clicked at most LoadMoreClicks times.
+	LoadMore       string
+	LoadMoreClicks int
+
+	// Interactions with the page run before scrolling.
+	Actions []Action
+
+	// Proxy of the capture and the hosts bypassing it, see also RotateProxy.
+	Proxy        string
+	ProxyBypass  []string
+	ProxyRotator *ProxyRotator
 }
 
 type ScreenshotOption func(*ScreenshotOptions)
@@ -679,11 +749,75 @@ func DumpHAR(b bool) ScreenshotOption {
 	}
 }
 
+// AuditPerformance collects Core Web Vitals, navigation timing and
+// DevTools performance metrics of the page into Screenshots.Performance.
+func AuditPerformance(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Performance = b
+	}
+}
+
+// AccessibilityTree dumps the full accessibility tree as JSON into
+// Screenshots.AXTree and reports obvious issues in Screenshots.AXIssues.
+func AccessibilityTree(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.AccessibilityTree = b
+	}
+}
+
+// DOMSnapshot captures the DOM tree with layout bounding boxes and computed
+// styles as JSON into Screenshots.DOM.
+func DOMSnapshot(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.DOMSnapshot = b
+	}
+}
+
+// SnapshotStyles sets the computed styles captured by DOMSnapshot,
+// defaults to DefaultSnapshotStyles.
+func SnapshotStyles(styles ...string) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.SnapshotStyles = styles
+	}
+}
+
+// Screencast records the page load and scrolling as an animated GIF into
+// Screenshots.Screencast.
+func Screencast(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.Screencast = b
+	}
+}
+
+// ScreencastFrames returns the frames of the screencast with timestamps in
+// Screenshots.Frames, it implies Screencast.
+func ScreencastFrames(b bool) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.ScreencastFrames = b
+		if b {
+			opts.Screencast = true
+		}
+	}
+}
+
+// FailOnStatus fails the capture with a *HTTPStatusError if the status code
+// of the main document is in any of the ranges, see ParseStatusRanges.
+func FailOnStatus(ranges ...StatusRange) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.FailOnStatus = ranges
+	}
+}
+
 type Files struct {
 	Image string
 	HTML  string
 	PDF   string
 	HAR   string
+
+	Performance string // JSON report of the performance audit
+	AXTree      string // JSON of the accessibility tree
+	DOM         string // JSON of the DOM snapshot
+	Screencast  string // Animated GIF of the page load
 }
 
 func AppendToFile(f Files) ScreenshotOption {
@@ -744,7 +878,8 @@ func Cookies(cookies []Cookie) ScreenshotOption {
 	}
 }
 
-// LocalStorage represents a local storage item.
+// LocalStorage represents a local storage item, or a session storage item
+// seeded by SessionStorage.
 type LocalStorage struct {
 	Key   string
 	Value string
@@ -3,6 +3,7 @@ package screenshot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -119,6 +121,8 @@ func TestScreenshotWithRemote(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer remote.Close()
+
 	shot, err := remote.Screenshot(ctx, input, ScaleFactor(1))
 	if err != nil {
 		if err == context.DeadlineExceeded {
@@ -523,3 +527,192 @@ func TestAppendToFile(t *testing.T) {
 		t.Error("Unexpected append har to file")
 	}
 }
+
+func TestScreenshotWithPerformance(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := newServer()
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dirname, err := os.MkdirTemp(os.TempDir(), "screenshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirname)
+
+	files := Files{Performance: path.Join(dirname, "performance.json")}
+	shot, err := Screenshot[Byte](ctx, input, AuditPerformance(true), AppendToFile(files))
+	if err != nil {
+		t.Fatal(err.Error(), http.StatusServiceUnavailable)
+	}
+
+	if shot.Performance == nil {
+		t.Fatal("unexpected performance report got nil")
+	}
+	if shot.Performance.Requests == 0 {
+		t.Error("unexpected performance report got zero requests")
+	}
+	if len(shot.Performance.Metrics) == 0 {
+		t.Error("unexpected performance report got empty metrics")
+	}
+	if !helper.Exists(files.Performance) {
+		t.Error("Unexpected write performance report to file")
+	}
+}
+
+func TestScreenshotFailOnStatus(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<html><head><title>Not Found</title></head></html>") // nolint:errcheck
+	}))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ranges, err := ParseStatusRanges("4xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, FailOnStatus(ranges...))
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("unexpected error got %v instead of HTTPStatusError", err)
+	}
+	if shot == nil || shot.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected status code of main document got %v", shot)
+	}
+	if shot.Image != nil {
+		t.Error("unexpected capture rejected page")
+	}
+}
+
+func TestScreenshotWithEvents(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := newServer()
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var events []Event
+	_, err = Screenshot[Byte](ctx, input, OnEvent(func(ev Event) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) == 0 {
+		t.Fatal("unexpected no events")
+	}
+	// Lifecycle events may still be delivered after Finished.
+	allocated, navigating, finished, nFinished := -1, -1, -1, 0
+	for i, ev := range events {
+		switch ev.(type) {
+		case BrowserAllocated:
+			if allocated < 0 {
+				allocated = i
+			}
+		case NavigationStarted:
+			if navigating < 0 {
+				navigating = i
+			}
+		case Finished:
+			finished = i
+			nFinished++
+		}
+	}
+	if allocated < 0 || navigating < 0 || allocated > navigating {
+		t.Errorf("unexpected order of events, BrowserAllocated at %d and NavigationStarted at %d", allocated, navigating)
+	}
+	if nFinished != 1 {
+		t.Errorf("unexpected number of Finished events got %d instead of 1", nFinished)
+	}
+	if finished < navigating {
+		t.Errorf("unexpected order of events, NavigationStarted at %d and Finished at %d", navigating, finished)
+	}
+	produced := false
+	for _, ev := range events {
+		if ev, ok := ev.(ArtifactProduced); ok && ev.Stage == StageScreenshot && ev.Size > 0 {
+			produced = true
+		}
+	}
+	if !produced {
+		t.Error("unexpected events without screenshot produced")
+	}
+}
+
+func TestScreenshotRemoveSelectors(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body style="overflow: hidden">
+<div id="popup">Subscribe to our newsletter</div>
+<h1>Example Domain</h1>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, RawHTML(true), RemoveSelectors("#popup"), HideSelectors("h1"))
+	if err != nil {
+		t.Fatal(err.Error(), http.StatusServiceUnavailable)
+	}
+
+	html := string(shot.HTML)
+	if strings.Contains(html, "newsletter") {
+		t.Error("unexpected removed element in html")
+	}
+	if !strings.Contains(html, "display: none !important") {
+		t.Error("unexpected hidden element without style sheet")
+	}
+}
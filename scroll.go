@@ -0,0 +1,331 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	defaultScrollStep     = 150
+	defaultScrollInterval = 150 * time.Millisecond
+)
+
+// ScrollStep sets the distance in pixels of each scroll step, defaults to 150.
+func ScrollStep(px int64) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.ScrollStep = px
+	}
+}
+
+// ScrollInterval sets the delay between scroll steps, defaults to 150ms.
+func ScrollInterval(d time.Duration) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.ScrollInterval = d
+	}
+}
+
+// MaxPageHeight stops scrolling once the page is scrolled down to the height
+// in pixels, and limits the height of the screenshot, zero means no limit.
+func MaxPageHeight(px int64) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.MaxPageHeight = px
+	}
+}
+
+// LoadMore clicks the element matching the selector, such as a "load more"
+// button, at most n times after scrolling to the bottom, scrolling down after
+// each click.
+func LoadMore(selector string, n int) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.LoadMore = selector
+		opts.LoadMoreClicks = n
+	}
+}
+
+// MaxScrolls limits the number of scroll steps, zero means scrolling until
+// the bottom of the page. Reaching the limit is not an error.
+func MaxScrolls(n int) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.MaxScrolls = n
+	}
+}
+
+// This script scrolls down the page one step. It reports whether scrolled to the bottom
+// if the current height is not less than the total height, along with the offset
+// and the height for progress. If an exception occurs, it reports done for termilate.
+//
+// Due to accuracy, the `currentHeight` may always be less than `the'scrollHeight`, add 1px
+// `currentHeight` to ensure scrolled to bottom.
+const scrollPageScript = `(distance) => {
+    let scrollHeight = 0;
+    let currentHeight = 0;
+
+    try {
+        scrollHeight = document.documentElement.scrollHeight || document.body.scrollHeight;
+        currentHeight = window.innerHeight + window.pageYOffset + 1;
+    } catch (e) {
+        return {done: true, offset: 0, height: 0};
+    }
+
+    window.scrollBy(0, distance);
+    return {done: currentHeight >= scrollHeight, offset: currentHeight, height: scrollHeight};
+}`
+
+// This script marks the visible elements scrolling their own content, such as
+// the main pane of a web application, which are not scrolled with the page.
+const markScrollersScript = `() => {
+    let marked = 0;
+    for (const el of document.querySelectorAll('body *')) {
+        if (el.scrollHeight <= el.clientHeight + 1 || el.clientHeight < 50) continue;
+        const overflow = getComputedStyle(el).overflowY;
+        if (overflow !== 'auto' && overflow !== 'scroll') continue;
+        el.setAttribute('data-screenshot-scroll', '');
+        marked++;
+    }
+    return marked;
+}`
+
+// This script scrolls down the marked containers one step, it reports done
+// once all of them are scrolled to the bottom.
+const scrollContainersScript = `(distance) => {
+    let done = true, offset = 0, height = 0;
+    for (const el of document.querySelectorAll('[data-screenshot-scroll]')) {
+        const current = el.clientHeight + el.scrollTop + 1;
+        offset += Math.min(current, el.scrollHeight);
+        height += el.scrollHeight;
+        if (current >= el.scrollHeight) continue;
+        el.scrollBy(0, distance);
+        done = false;
+    }
+    return {done, offset, height};
+}`
+
+// This script makes lazy images and frames load eagerly, and swaps in the
+// sources of the common lazy-loading libraries kept in data attributes.
+const loadLazyScript = `() => {
+    document.querySelectorAll('img[loading="lazy"], iframe[loading="lazy"]').forEach((el) => { el.loading = 'eager'; });
+    const placeholder = (el) => !el.getAttribute('src') || el.getAttribute('src').startsWith('data:');
+    for (const el of document.querySelectorAll('img, source, iframe')) {
+        const src = el.dataset.src || el.dataset.lazySrc || el.dataset.original;
+        const srcset = el.dataset.srcset || el.dataset.lazySrcset;
+        if (srcset && !el.getAttribute('srcset')) el.setAttribute('srcset', srcset);
+        if (src && el.tagName !== 'SOURCE' && placeholder(el)) el.setAttribute('src', src);
+    }
+}`
+
+// This script waits for the images of the page to be decoded, for at most the timeout.
+const decodeImagesScript = `(timeout) => {
+    const images = Array.from(document.images).filter((img) => img.currentSrc || img.src);
+    const decoded = Promise.all(images.map((img) => img.decode().catch(() => {})));
+    return Promise.race([decoded, new Promise((resolve) => setTimeout(resolve, timeout))]).then(() => true);
+}`
+
+// This script scrolls back the page and the marked containers to the top,
+// so that sticky elements are rendered at their original position.
+const scrollTopScript = `() => {
+    document.querySelectorAll('[data-screenshot-scroll]').forEach((el) => {
+        el.scrollTo(0, 0);
+        el.removeAttribute('data-screenshot-scroll');
+    });
+    window.scrollTo(0, 0);
+    return true;
+}`
+
+// This script finds the first visible element matching the selector, and clicks
+// it if allowed and the page is lower than the max height, zero means no limit.
+// It reports the height of the page before clicking.
+const clickLoadMoreScript = `(selector, click, maxHeight) => {
+    const height = document.documentElement.scrollHeight || document.body.scrollHeight;
+    const visible = (el) => !!(el.offsetWidth || el.offsetHeight || el.getClientRects().length);
+    const el = Array.from(document.querySelectorAll(selector)).find(visible);
+    if (!el || el.disabled) return {found: false, clicked: false, height};
+    if (!click || (maxHeight > 0 && height >= maxHeight)) return {found: true, clicked: false, height};
+    el.scrollIntoView({block: 'center'});
+    el.click();
+    return {found: true, clicked: true, height};
+}`
+
+const pageHeightScript = `document.documentElement.scrollHeight || document.body.scrollHeight`
+
+// scrollToBottom scrolls down the page and then its nested scroll containers
+// step by step to trigger lazy loading, clicks the load-more element if any,
+// waits for the images to be decoded, and scrolls back to the top. It sets
+// truncated if it stopped before the end of the page due to MaxScrolls,
+// MaxPageHeight, LoadMore or running out of time.
+//
+// https://github.com/chromedp/chromedp/blob/875d6f4a3453149639d7fa83a2fa473b743fc33f/poll.go#L88-L127
+func scrollToBottom(ctx context.Context, url string, truncated *bool, options ScreenshotOptions) chromedp.Action {
+	// Time reserved for the stages after scrolling.
+	idle := 5 * time.Second
+	timeout := 15 * time.Second
+	deadline, ok := ctx.Deadline()
+	if ok {
+		timeout = deadline.Sub(time.Now())
+		if timeout > idle {
+			timeout -= idle
+		}
+	}
+	step := options.ScrollStep
+	if step <= 0 {
+		step = defaultScrollStep
+	}
+	interval := options.ScrollInterval
+	if interval <= 0 {
+		interval = defaultScrollInterval
+	}
+
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		deadline := time.Now().Add(timeout)
+		scrolls := 0
+
+		wait := func(d time.Duration) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(d):
+				return nil
+			}
+		}
+
+		// Scroll down step by step every interval, the same as
+		// `chromedp.PollFunction` which does not report the progress.
+		// It reports whether the bottom is reached within the limits.
+		scroll := func(script string, bounded bool) (bool, error) {
+			expr := fmt.Sprintf("(%s)(%d)", script, step)
+			for {
+				if options.MaxScrolls > 0 && scrolls >= options.MaxScrolls {
+					return false, nil
+				}
+				var res struct {
+					Done   bool    `json:"done"`
+					Offset float64 `json:"offset"`
+					Height float64 `json:"height"`
+				}
+				if err := chromedp.Evaluate(expr, &res).Do(ctx); err != nil {
+					return false, err
+				}
+				scrolls++
+				options.emit(ScrollProgress{URL: url, Offset: res.Offset, Height: res.Height})
+				if res.Done {
+					return true, nil
+				}
+				if bounded && options.MaxPageHeight > 0 && res.Offset >= float64(options.MaxPageHeight) {
+					return false, nil
+				}
+				if time.Now().After(deadline) {
+					// Out of time, e.g. an infinite feed, capture what is loaded.
+					return false, nil
+				}
+				if err := wait(interval); err != nil {
+					return false, err
+				}
+			}
+		}
+
+		// Click the load-more element up to n times, scrolling down after
+		// each click. It reports whether there is nothing more to load.
+		loadMore := func() (bool, error) {
+			arg, err := json.Marshal(options.LoadMore)
+			if err != nil {
+				return false, err
+			}
+			for i := 0; ; i++ {
+				expr := fmt.Sprintf("(%s)(%s, %t, %d)", clickLoadMoreScript, arg, i < options.LoadMoreClicks, options.MaxPageHeight)
+				var res struct {
+					Found   bool    `json:"found"`
+					Clicked bool    `json:"clicked"`
+					Height  float64 `json:"height"`
+				}
+				if err := chromedp.Evaluate(expr, &res).Do(ctx); err != nil {
+					return false, err
+				}
+				if !res.Clicked {
+					// There is more to load if the element is still there.
+					return !res.Found, nil
+				}
+				// Wait for the page to grow, at most 5 seconds.
+				for start := time.Now(); time.Since(start) < 5*time.Second; {
+					if err := wait(interval); err != nil {
+						return false, err
+					}
+					var height float64
+					if err := chromedp.Evaluate(pageHeightScript, &height).Do(ctx); err != nil {
+						return false, err
+					}
+					if height > res.Height {
+						break
+					}
+				}
+				done, err := scroll(scrollPageScript, true)
+				if err != nil || !done {
+					return done, err
+				}
+			}
+		}
+
+		complete := true
+		track := func(fn func() (bool, error)) chromedp.ActionFunc {
+			return func(context.Context) error {
+				done, err := fn()
+				complete = complete && done
+				return err
+			}
+		}
+
+		var marked int
+		err := chromedp.Tasks{
+			chromedp.Evaluate(fmt.Sprintf("(%s)()", loadLazyScript), nil),
+			track(func() (bool, error) {
+				return scroll(scrollPageScript, true)
+			}),
+			track(func() (bool, error) {
+				if options.LoadMore == "" || options.LoadMoreClicks <= 0 || !complete {
+					return true, nil
+				}
+				return loadMore()
+			}),
+			chromedp.Evaluate(fmt.Sprintf("(%s)()", markScrollersScript), &marked),
+			track(func() (bool, error) {
+				if marked == 0 {
+					return true, nil
+				}
+				return scroll(scrollContainersScript, false)
+			}),
+		}.Do(ctx)
+
+		// Wait for the images to be decoded at most 5 seconds, within the
+		// time left apart from the reserve of the later stages.
+		decode := 5 * time.Second
+		if d, ok := ctx.Deadline(); ok {
+			if left := time.Until(d) - idle; left < decode {
+				decode = left
+			}
+		}
+		if decode < 0 {
+			decode = 0
+		}
+
+		// Load and decode the content loaded so far and scroll back to the
+		// top even if scrolling failed, e.g. an infinite feed hit the deadline.
+		if ferr := (chromedp.Tasks{
+			chromedp.Evaluate(fmt.Sprintf("(%s)()", loadLazyScript), nil),
+			chromedp.Evaluate(fmt.Sprintf("(%s)(%d)", decodeImagesScript, decode.Milliseconds()), nil, awaitPromise),
+			chromedp.Evaluate(fmt.Sprintf("(%s)()", scrollTopScript), nil),
+			chromedp.Sleep(interval),
+		}).Do(ctx); err == nil {
+			err = ferr
+		}
+		if truncated != nil {
+			*truncated = !complete
+		}
+		return err
+	})
+}
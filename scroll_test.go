@@ -0,0 +1,146 @@
+package screenshot
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wabarc/helper"
+)
+
+func TestScreenshotLazyLoad(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body>
+<div id="pane" style="height: 200px; overflow: auto"><div style="height: 2000px"></div></div>
+<img data-src="/lazy.png" src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+<script>
+document.getElementById('pane').addEventListener('scroll', (e) => { document.title = 'scrolled'; });
+</script>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, RawHTML(true), ScrollStep(500), ScrollInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shot.Title != "scrolled" {
+		t.Errorf("unexpected nested container not scrolled, title: %s", shot.Title)
+	}
+	if !strings.Contains(string(shot.HTML), `src="/lazy.png"`) {
+		t.Error("unexpected lazy image without its source")
+	}
+}
+
+func TestScreenshotLoadMore(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>0</title></head>
+<body>
+<div id="feed"><div style="height: 1000px">item</div></div>
+<button id="more">Load more</button>
+<script>
+document.getElementById('more').addEventListener('click', () => {
+  const item = document.createElement('div');
+  item.style.height = '1000px';
+  item.textContent = 'item';
+  document.getElementById('feed').appendChild(item);
+  document.title = String(Number(document.title) + 1);
+});
+</script>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, LoadMore("#more", 2), ScrollStep(1000), ScrollInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shot.Title != "2" {
+		t.Errorf("unexpected clicks on load-more element, got %s instead of 2", shot.Title)
+	}
+	if !shot.Truncated {
+		t.Error("unexpected capture not truncated")
+	}
+}
+
+func TestScreenshotInfiniteScroll(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body>
+<img data-src="/lazy.png" src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+<div id="feed"><div style="height: 1000px">item</div></div>
+<script>
+window.addEventListener('scroll', () => {
+  const item = document.createElement('div');
+  item.style.height = '1000px';
+  item.textContent = 'item';
+  document.getElementById('feed').appendChild(item);
+  document.title = 'y' + Math.round(window.scrollY);
+});
+</script>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shot, err := Screenshot[Byte](ctx, input, RawHTML(true), ScrollStep(1000), ScrollInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !shot.Truncated {
+		t.Error("unexpected capture not truncated")
+	}
+	if shot.Title != "y0" {
+		t.Errorf("unexpected page not scrolled back to the top, title: %s", shot.Title)
+	}
+	if !strings.Contains(string(shot.HTML), `src="/lazy.png"`) {
+		t.Error("unexpected lazy image without its source")
+	}
+}
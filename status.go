@@ -0,0 +1,98 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// StatusRange represents an inclusive range of HTTP status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether the code is in the range.
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// ParseStatusRanges parses a comma-separated list of HTTP status codes,
+// ranges and classes, such as "404,410", "500-599" or "4xx,5xx".
+func ParseStatusRanges(spec string) (ranges []StatusRange, err error) {
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+		var r StatusRange
+		switch {
+		case len(s) == 3 && strings.HasSuffix(s, "xx"):
+			class, er := strconv.Atoi(s[:1])
+			if er != nil {
+				return nil, fmt.Errorf("invalid status class %q", s)
+			}
+			r = StatusRange{Min: class * 100, Max: class*100 + 99}
+		case strings.Contains(s, "-"):
+			parts := strings.SplitN(s, "-", 2)
+			min, er := strconv.Atoi(strings.TrimSpace(parts[0]))
+			if er != nil {
+				return nil, fmt.Errorf("invalid status range %q", s)
+			}
+			max, er := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if er != nil || max < min {
+				return nil, fmt.Errorf("invalid status range %q", s)
+			}
+			r = StatusRange{Min: min, Max: max}
+		default:
+			code, er := strconv.Atoi(s)
+			if er != nil {
+				return nil, fmt.Errorf("invalid status code %q", s)
+			}
+			r = StatusRange{Min: code, Max: code}
+		}
+		if r.Min < 100 || r.Max > 599 {
+			return nil, fmt.Errorf("status %q out of range 100-599", s)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// rejectStatus reports whether the code matches any of the ranges.
+func rejectStatus(code int, ranges []StatusRange) bool {
+	for _, r := range ranges {
+		if r.Contains(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMainFrame reports whether the frame is the main frame of the target,
+// which shares the id with the target.
+func isMainFrame(ctx context.Context, id cdp.FrameID) bool {
+	c := chromedp.FromContext(ctx)
+	return c != nil && c.Target != nil && string(c.Target.TargetID) == string(id)
+}
+
+func responseHeader(headers network.Headers) http.Header {
+	header := make(http.Header, len(headers))
+	for k, v := range headers {
+		// Multiple values of a header are joined by newline.
+		for _, s := range strings.Split(fmt.Sprint(v), "\n") {
+			header.Add(k, s)
+		}
+	}
+	return header
+}
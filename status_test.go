@@ -0,0 +1,64 @@
+package screenshot
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestParseStatusRanges(t *testing.T) {
+	tests := []struct {
+		spec    string
+		reject  []int
+		accept  []int
+		invalid bool
+	}{
+		{spec: "404", reject: []int{404}, accept: []int{200, 403, 405}},
+		{spec: "404, 410", reject: []int{404, 410}, accept: []int{200, 405}},
+		{spec: "500-503", reject: []int{500, 503}, accept: []int{499, 504}},
+		{spec: "4xx,5XX", reject: []int{400, 451, 599}, accept: []int{200, 301, 399}},
+		{spec: "", accept: []int{200, 404, 500}},
+		{spec: "abc", invalid: true},
+		{spec: "503-500", invalid: true},
+		{spec: "9xx", invalid: true},
+		{spec: "42", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			ranges, err := ParseStatusRanges(test.spec)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("unexpected parse %q without error", test.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, code := range test.reject {
+				if !rejectStatus(code, ranges) {
+					t.Errorf("unexpected status %d accepted by %q", code, test.spec)
+				}
+			}
+			for _, code := range test.accept {
+				if rejectStatus(code, ranges) {
+					t.Errorf("unexpected status %d rejected by %q", code, test.spec)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseHeader(t *testing.T) {
+	header := responseHeader(network.Headers{
+		"Content-Type": "text/html",
+		"set-cookie":   "foo=bar\nzoo=zoo",
+	})
+	if v := header.Get("Content-Type"); v != "text/html" {
+		t.Errorf("unexpected header got %s instead of %s", v, "text/html")
+	}
+	if v := header.Values("Set-Cookie"); len(v) != 2 {
+		t.Errorf("unexpected number of header values got %d instead of %d", len(v), 2)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2026 Wayback Archiver. All rights reserved.
+// Use of this source code is governed by the GNU GPL v3
+// license that can be found in the LICENSE file.
+
+package screenshot // import "github.com/wabarc/screenshot"
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v2"
+)
+
+// Path of the blank page of the origin, served by the browser itself, on
+// which the storage of the origin is seeded before navigating to the page.
+const seedPath = "/.well-known/screenshot-seed"
+
+// IndexedDBItem represents a value of an IndexedDB object store.
+type IndexedDBItem struct {
+	Database string `yaml:"database" json:"database"` // Name of the database.
+	Store    string `yaml:"store" json:"store"`       // Name of the object store, created if missing.
+	Key      string `yaml:"key" json:"key"`
+	// Value stored as is, which may be a string, number, boolean, list or
+	// map, i.e. any value which can be represented in JSON.
+	Value interface{} `yaml:"value" json:"value"`
+	Host  string      `yaml:"host,omitempty" json:"-"`
+}
+
+// UnmarshalYAML converts the maps of the value decoded from YAML to the ones
+// which can be represented in JSON.
+func (item *IndexedDBItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain IndexedDBItem
+	if err := unmarshal((*plain)(item)); err != nil {
+		return err
+	}
+	value, err := jsonValue(item.Value)
+	if err != nil {
+		return fmt.Errorf("indexed-db %s: %w", item.Key, err)
+	}
+	item.Value = value
+	return nil
+}
+
+// UnmarshalYAML accepts values of any type, values other than strings are
+// stored as JSON, such as {"theme": "dark"}, so that the state of apps can
+// be seeded.
+func (s *LocalStorage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var item struct {
+		Key   string      `yaml:"key"`
+		Value interface{} `yaml:"value"`
+		Host  string      `yaml:"host"`
+	}
+	if err := unmarshal(&item); err != nil {
+		return err
+	}
+	s.Key, s.Host = item.Key, item.Host
+	switch v := item.Value.(type) {
+	case nil:
+		s.Value = ""
+	case string:
+		s.Value = v
+	default:
+		value, err := jsonValue(v)
+		if err != nil {
+			return fmt.Errorf("storage %s: %w", item.Key, err)
+		}
+		buf, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("storage %s: %w", item.Key, err)
+		}
+		s.Value = string(buf)
+	}
+	return nil
+}
+
+// jsonValue converts the maps decoded from YAML, which have keys of any type,
+// to maps with string keys.
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			k, ok := key.(string)
+			if !ok {
+				k = fmt.Sprint(key)
+			}
+			converted, err := jsonValue(val)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			converted, err := jsonValue(val)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = converted
+		}
+		return l, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("unsupported value %v", v)
+		}
+	}
+	return v, nil
+}
+
+// ImportSessionStorage imports session storage items by given byte with yaml
+// configuration, in the format of ImportStorage under the session-storage key.
+func ImportSessionStorage(r []byte) (storage []LocalStorage, err error) {
+	type configs struct {
+		SessionStorage map[string][]LocalStorage `yaml:"session-storage"`
+	}
+	var cfg configs
+	if err := yaml.Unmarshal(r, &cfg); err != nil {
+		return nil, err
+	}
+	for domain, items := range cfg.SessionStorage {
+		for i := range items {
+			if items[i].Host == "" {
+				items[i].Host = domain
+			}
+			storage = append(storage, items[i])
+		}
+	}
+	return storage, nil
+}
+
+// ImportIndexedDB imports IndexedDB values by given byte with yaml configuration.
+// Format:
+// indexed-db:
+//
+//	example.com:
+//	  - database: 'app'
+//	    store: 'settings'
+//	    key: 'theme'
+//	    value: 'dark'
+func ImportIndexedDB(r []byte) (items []IndexedDBItem, err error) {
+	type configs struct {
+		IndexedDB map[string][]IndexedDBItem `yaml:"indexed-db"`
+	}
+	var cfg configs
+	if err := yaml.Unmarshal(r, &cfg); err != nil {
+		return nil, err
+	}
+	for domain, values := range cfg.IndexedDB {
+		for i := range values {
+			if values[i].Host == "" {
+				values[i].Host = domain
+			}
+			items = append(items, values[i])
+		}
+	}
+	return items, nil
+}
+
+// SessionStorage seeds the session storage of the origin of the page.
+func SessionStorage(storage []LocalStorage) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.SessionStorage = storage
+	}
+}
+
+// IndexedDB seeds the IndexedDB object stores of the origin of the page.
+func IndexedDB(items []IndexedDBItem) ScreenshotOption {
+	return func(opts *ScreenshotOptions) {
+		opts.IndexedDB = items
+	}
+}
+
+// seedItems is the storage of an origin seeded by seedScript.
+type seedItems struct {
+	Local   []storageItem   `json:"local"`
+	Session []storageItem   `json:"session"`
+	IDB     []IndexedDBItem `json:"idb"`
+}
+
+type storageItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// storageOf returns the storage items of the host of the page.
+func storageOf(u *url.URL, options ScreenshotOptions) (items seedItems) {
+	for _, item := range options.Storage {
+		if item.Host == u.Host {
+			items.Local = append(items.Local, storageItem{Key: item.Key, Value: item.Value})
+		}
+	}
+	for _, item := range options.SessionStorage {
+		if item.Host == u.Host {
+			items.Session = append(items.Session, storageItem{Key: item.Key, Value: item.Value})
+		}
+	}
+	for _, item := range options.IndexedDB {
+		if item.Host == u.Host {
+			items.IDB = append(items.IDB, item)
+		}
+	}
+	return items
+}
+
+const seedScript = `async (items) => {
+  for (const { key, value } of items.local || []) localStorage.setItem(key, value);
+  for (const { key, value } of items.session || []) sessionStorage.setItem(key, value);
+
+  const open = (name, version, stores) => new Promise((resolve, reject) => {
+    const req = version ? indexedDB.open(name, version) : indexedDB.open(name);
+    req.onupgradeneeded = () => {
+      for (const store of stores) {
+        if (!req.result.objectStoreNames.contains(store)) req.result.createObjectStore(store);
+      }
+    };
+    req.onsuccess = () => resolve(req.result);
+    req.onerror = () => reject(req.error);
+    req.onblocked = () => reject(new Error('database ' + name + ' is blocked'));
+  });
+  const databases = new Map();
+  for (const item of items.idb || []) {
+    if (!databases.has(item.database)) databases.set(item.database, []);
+    databases.get(item.database).push(item);
+  }
+  for (const [name, entries] of databases) {
+    const stores = [...new Set(entries.map((e) => e.store))];
+    let db = await open(name, 0, stores);
+    if (stores.some((s) => !db.objectStoreNames.contains(s))) {
+      // Object stores are only created by upgrading the database.
+      const version = db.version + 1;
+      db.close();
+      db = await open(name, version, stores);
+    }
+    await new Promise((resolve, reject) => {
+      const tx = db.transaction(stores, 'readwrite');
+      for (const e of entries) tx.objectStore(e.store).put(e.value, e.key);
+      tx.oncomplete = () => resolve();
+      tx.onerror = () => reject(tx.error);
+      tx.onabort = () => reject(tx.error);
+    });
+    db.close();
+  }
+  return true;
+}`
+
+// seedStorage seeds the local storage, session storage and IndexedDB of the
+// origin of the page on a blank page of the origin, which is served by the
+// Fetch domain without requesting the server. It runs before the network
+// domain is enabled, so that the blank page is not recorded.
+func seedStorage(u *url.URL, options ScreenshotOptions) chromedp.Action {
+	items := storageOf(u, options)
+	if len(items.Local)+len(items.Session)+len(items.IDB) == 0 {
+		return chromedp.Tasks{}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return chromedp.Tasks{}
+	}
+
+	seedURL := u.Scheme + "://" + u.Host + seedPath
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		body := base64.StdEncoding.EncodeToString([]byte("<!DOCTYPE html><title></title>"))
+		// The listener is removed once seeded.
+		lctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		chromedp.ListenTarget(lctx, func(v interface{}) {
+			ev, ok := v.(*fetch.EventRequestPaused)
+			if !ok || ev.Request.URL != seedURL {
+				return
+			}
+			go func() {
+				ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				defer cancel()
+				_ = chromedp.Run(ctx, fetch.FulfillRequest(ev.RequestID, 200).
+					WithResponseHeaders([]*fetch.HeaderEntry{{Name: "Content-Type", Value: "text/html; charset=utf-8"}}).
+					WithBody(body))
+			}()
+		})
+		patterns := []*fetch.RequestPattern{{URLPattern: seedURL, RequestStage: fetch.RequestStageRequest}}
+		if err := fetch.Enable().WithPatterns(patterns).Do(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			_ = fetch.Disable().Do(ctx)
+		}()
+
+		return chromedp.Tasks{
+			chromedp.Navigate(seedURL),
+			callFunction(seedScript, nil, items),
+		}.Do(ctx)
+	})
+}
@@ -0,0 +1,180 @@
+package screenshot
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wabarc/helper"
+)
+
+func TestImportSessionStorage(t *testing.T) {
+	f := `session-storage:
+  example.com:
+    - key: 'tab'
+      value: 'reviews'
+    - key: 'foo'
+      value: 'bar'
+      host: 'www.example.com'`
+	storage, err := ImportSessionStorage(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(storage); n != 2 {
+		t.Fatalf("unexpected number of session storage items got %d instead of %d", n, 2)
+	}
+	if storage[0].Host != "example.com" || storage[1].Host != "www.example.com" {
+		t.Errorf("unexpected hosts of session storage items got %+v", storage)
+	}
+}
+
+func TestImportIndexedDB(t *testing.T) {
+	f := `indexed-db:
+  example.com:
+    - database: 'app'
+      store: 'settings'
+      key: 'theme'
+      value: 'dark'`
+	items, err := ImportIndexedDB(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Host != "example.com" || items[0].Store != "settings" {
+		t.Errorf("unexpected IndexedDB items got %+v", items)
+	}
+}
+
+func TestImportStorageTypedValues(t *testing.T) {
+	f := `local-storage:
+  example.com:
+    - key: 'state'
+      value:
+        user: {id: 1, name: "O'Brien"}
+        tags: [a, b]
+    - key: 'count'
+      value: 3
+    - key: 'script'
+      value: "'); alert(1); ('\n"
+indexed-db:
+  example.com:
+    - database: 'app'
+      store: 'kv'
+      key: 'user'
+      value: {id: 1, roles: [admin]}`
+	storage, err := ImportStorage(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(storage); n != 3 {
+		t.Fatalf("unexpected number of storage items got %d instead of %d", n, 3)
+	}
+	if got, want := storage[0].Value, `{"tags":["a","b"],"user":{"id":1,"name":"O'Brien"}}`; got != want {
+		t.Errorf("unexpected value got %s instead of %s", got, want)
+	}
+	if got, want := storage[1].Value, "3"; got != want {
+		t.Errorf("unexpected value got %s instead of %s", got, want)
+	}
+	if got, want := storage[2].Value, "'); alert(1); ('\n"; got != want {
+		t.Errorf("unexpected value got %q instead of %q", got, want)
+	}
+
+	items, err := ImportIndexedDB(Byte(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("unexpected number of IndexedDB items got %d instead of %d", len(items), 1)
+	}
+	value, ok := items[0].Value.(map[string]interface{})
+	if !ok || value["id"] != 1 {
+		t.Errorf("unexpected IndexedDB value got %#v", items[0].Value)
+	}
+}
+
+func TestStorageOf(t *testing.T) {
+	u, _ := url.Parse("https://example.com/page")
+	items := storageOf(u, ScreenshotOptions{
+		Storage:        []LocalStorage{{Key: "a", Value: "1", Host: "example.com"}, {Key: "b", Value: "2", Host: "example.org"}},
+		SessionStorage: []LocalStorage{{Key: "c", Value: "3", Host: "example.com"}},
+		IndexedDB:      []IndexedDBItem{{Database: "app", Store: "kv", Key: "d", Value: "4", Host: "www.example.com"}},
+	})
+	if len(items.Local) != 1 || len(items.Session) != 1 || len(items.IDB) != 0 {
+		t.Errorf("unexpected storage items got %+v", items)
+	}
+}
+
+func TestScreenshotWithStorage(t *testing.T) {
+	binPath := helper.FindChromeExecPath()
+	if _, err := exec.LookPath(binPath); err != nil {
+		t.Skip("Chrome headless browser no found, skipped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ts := httptest.NewServer(writeHTML(`
+<html>
+<head><title>Example Domain</title></head>
+<body>
+<h1>Example Domain</h1>
+<p id="local"></p>
+<p id="session"></p>
+<p id="idb"></p>
+<script>
+document.getElementById('local').textContent = 'local:' + localStorage.getItem('foo');
+document.getElementById('session').textContent = 'session:' + sessionStorage.getItem('tab');
+const req = indexedDB.open('app');
+req.onsuccess = () => {
+  const get = req.result.transaction('settings').objectStore('settings').get('theme');
+  get.onsuccess = () => { document.getElementById('idb').textContent = 'idb:' + get.result.name; };
+};
+</script>
+</body>
+</html>
+`))
+	defer ts.Close()
+
+	input, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var events []Event
+	shot, err := Screenshot[Byte](ctx, input, RawHTML(true),
+		OnEvent(func(ev Event) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+		Storage([]LocalStorage{{Key: "foo", Value: "it's \"quoted\"\n');", Host: input.Host}}),
+		SessionStorage([]LocalStorage{{Key: "tab", Value: "reviews", Host: input.Host}}),
+		IndexedDB([]IndexedDBItem{{Database: "app", Store: "settings", Key: "theme", Value: map[string]interface{}{"name": "dark"}, Host: input.Host}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := string(shot.HTML)
+	for _, want := range []string{`local:it's "quoted"`, "session:reviews", "idb:dark"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in html", want)
+		}
+	}
+
+	// The seed page is not reported.
+	mu.Lock()
+	defer mu.Unlock()
+	for _, ev := range events {
+		if _, ok := ev.(NavigationStarted); ok {
+			break
+		}
+		if ev, ok := ev.(LifecycleEvent); ok {
+			t.Errorf("unexpected lifecycle event %s before navigating", ev.Name)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package screenshot // import "github.com/wabarc/screenshot"
 
 import (
+	"context"
 	"mime"
 	"net/http"
 	"net/url"
@@ -12,6 +13,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/wabarc/helper"
 )
 
 func viewerEndpoint() string {
@@ -85,3 +90,39 @@ func wsURLReadTimeout() time.Duration {
 
 	return time.Duration(i) * time.Second
 }
+
+// setResult assigns buf to res, or writes buf to file and assigns the
+// file path to res, depending on the type of the result.
+func setResult[T As](res *T, buf []byte, file string) (err error) {
+	switch t := (interface{})(res).(type) {
+	case *Byte:
+		*t = buf
+	case *Path:
+		if err = helper.WriteFile(file, buf, perm); err != nil {
+			return &ArtifactError{Path: file, Err: err}
+		}
+		*t = Path(file)
+	}
+	return err
+}
+
+// callFunction calls the function on the global object of the page, waiting
+// for the returned promise. The arguments are serialized as JSON and passed
+// by Runtime.callFunctionOn, instead of being formatted into the script.
+func callFunction(fn string, res interface{}, args ...interface{}) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		global, exp, err := runtime.Evaluate("globalThis").Do(ctx)
+		if err != nil {
+			return err
+		}
+		if exp != nil {
+			return exp
+		}
+		defer func() {
+			_ = runtime.ReleaseObject(global.ObjectID).Do(ctx)
+		}()
+		return chromedp.CallFunctionOn(fn, res, func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
+			return p.WithObjectID(global.ObjectID).WithAwaitPromise(true)
+		}, args...).Do(ctx)
+	})
+}